@@ -0,0 +1,79 @@
+package notificationhubs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AdmMaxPayloadSize is the largest message ADM accepts, in bytes
+const AdmMaxPayloadSize = 6144
+
+// Limits of the ADM expiresAfter field
+const (
+	AdmMinExpiresAfter = time.Minute
+	AdmMaxExpiresAfter = 31 * 24 * time.Hour
+)
+
+type (
+	// AdmMessage is a typed Amazon Device Messaging message for KindleFormat notifications
+	AdmMessage struct {
+		// Data is delivered to the app, ADM only accepts string values
+		Data map[string]string
+		// ConsolidationKey collapses messages with the same key on an offline device
+		ConsolidationKey string
+		// ExpiresAfter is how long ADM keeps the message for an offline device,
+		// in whole seconds from AdmMinExpiresAfter to AdmMaxExpiresAfter. ADM uses one week when it is 0.
+		ExpiresAfter time.Duration
+	}
+
+	// admMessageJSON is the wire format of AdmMessage
+	admMessageJSON struct {
+		Data             map[string]string `json:"data"`
+		ConsolidationKey string            `json:"consolidationKey,omitempty"`
+		ExpiresAfter     int64             `json:"expiresAfter,omitempty"`
+	}
+)
+
+// NewAdmMessage returns a message delivering data
+func NewAdmMessage(data map[string]string) *AdmMessage {
+	return &AdmMessage{Data: data}
+}
+
+// Build validates the message and returns it as a KindleFormat notification
+func (m *AdmMessage) Build() (*Notification, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrorCodeInvalidPayload, "cannot encode ADM message", err)
+	}
+	if len(payload) > AdmMaxPayloadSize {
+		return nil, NewError(ErrorCodePayloadTooLarge, fmt.Sprintf("ADM message is %d bytes, the limit is %d", len(payload), AdmMaxPayloadSize))
+	}
+	return newNotification(KindleFormat, payload)
+}
+
+// Validate reports the first invalid field as a *ValidationError
+func (m *AdmMessage) Validate() error {
+	switch {
+	case len(m.Data) == 0:
+		return NewValidationError("data", "must not be empty", nil)
+	case m.ExpiresAfter == 0:
+	case m.ExpiresAfter%time.Second != 0:
+		return NewValidationError("expiresAfter", "must be a whole number of seconds", m.ExpiresAfter)
+	case m.ExpiresAfter < AdmMinExpiresAfter || m.ExpiresAfter > AdmMaxExpiresAfter:
+		return NewValidationError("expiresAfter", "must be between 1 minute and 31 days", m.ExpiresAfter)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing expiresAfter in seconds
+func (m AdmMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(admMessageJSON{
+		Data:             m.Data,
+		ConsolidationKey: m.ConsolidationKey,
+		ExpiresAfter:     int64(m.ExpiresAfter / time.Second),
+	})
+}
@@ -0,0 +1,190 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func Test_AdmMessage(t *testing.T) {
+	message := NewAdmMessage(map[string]string{"message": "Order shipped", "orderId": "42"})
+	message.ConsolidationKey = "orders"
+	message.ExpiresAfter = 24 * time.Hour
+
+	notification, err := message.Build()
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if notification.Format != KindleFormat {
+		t.Errorf(errfmt, "format", KindleFormat, notification.Format)
+	}
+	want := `{"data":{"message":"Order shipped","orderId":"42"},"consolidationKey":"orders","expiresAfter":86400}`
+	if string(notification.Payload) != want {
+		t.Errorf(errfmt, "payload", want, string(notification.Payload))
+	}
+}
+
+func Test_AdmMessageValidation(t *testing.T) {
+	data := map[string]string{"k": "v"}
+	tests := []struct {
+		name    string
+		message AdmMessage
+		field   string
+	}{
+		{name: "no data", message: AdmMessage{}, field: "data"},
+		{name: "fractional expiry", message: AdmMessage{Data: data, ExpiresAfter: 90500 * time.Millisecond}, field: "expiresAfter"},
+		{name: "expiry too short", message: AdmMessage{Data: data, ExpiresAfter: 30 * time.Second}, field: "expiresAfter"},
+		{name: "expiry too long", message: AdmMessage{Data: data, ExpiresAfter: AdmMaxExpiresAfter + time.Second}, field: "expiresAfter"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.message.Build()
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != test.field {
+				t.Errorf(errfmt, "validation error", test.field, err)
+			}
+		})
+	}
+
+	_, err := NewAdmMessage(map[string]string{"blob": strings.Repeat("x", AdmMaxPayloadSize)}).Build()
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodePayloadTooLarge {
+		t.Errorf(errfmt, "error", ErrorCodePayloadTooLarge, err)
+	}
+}
+
+func Test_RegisterAdm(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		registration     = Registration{
+			Tags:               "kindle,tag2",
+			DeviceID:           "amzn1.adm-registration.v3.sample",
+			NotificationFormat: KindleFormat,
+		}
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if !strings.Contains(string(body), "<AdmRegistrationDescription") || !strings.Contains(string(body), "<AdmRegistrationId>amzn1.adm-registration.v3.sample</AdmRegistrationId>") {
+			t.Errorf(errfmt, "registration body", "AdmRegistrationDescription", string(body))
+		}
+		data, err := os.ReadFile("./fixtures/admRegistrationResult.xml")
+		return data, nil, err
+	}
+
+	_, result, err := nhub.Register(context.Background(), registration)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	expected := &RegistrationContent{
+		Format: KindleFormat,
+		Target: AdmPlatform,
+		RegisteredDevice: &RegisteredDevice{
+			DeviceID:       "amzn1.adm-registration.v3.sample",
+			ETag:           "1",
+			ExpirationTime: &endOfEpoch,
+			RegistrationID: "6271498734016873721-2283461940582391843-2",
+			Tags:           []string{"kindle", "tag2"},
+		},
+	}
+	if !reflect.DeepEqual(result.RegistrationContent, expected) {
+		t.Errorf(errfmt, "registration content", expected, result.RegistrationContent)
+	}
+}
+
+func Test_RegisterAdmTemplate(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		registration     = TemplateRegistration{
+			Tags:     "kindle",
+			DeviceID: "amzn1.adm-registration.v3.sample",
+			Template: `{"data":{"message":"$(message)"}}`,
+			Platform: AdmPlatform,
+		}
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if !strings.Contains(string(body), "<AdmTemplateRegistrationDescription") || !strings.Contains(string(body), `<![CDATA[{"data":{"message":"$(message)"}}]]>`) {
+			t.Errorf(errfmt, "registration body", "AdmTemplateRegistrationDescription", string(body))
+		}
+		data, err := os.ReadFile("./fixtures/admTemplateRegistrationResult.xml")
+		return data, nil, err
+	}
+
+	_, result, err := nhub.RegisterWithTemplate(context.Background(), registration)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	content := result.RegistrationContent
+	if content.Format != Template || content.Target != AdmTemplatePlatform {
+		t.Errorf(errfmt, "format and target", "template admtemplate", content)
+	}
+	if content.RegisteredDevice.DeviceID != registration.DeviceID || content.RegisteredDevice.Template != registration.Template {
+		t.Errorf(errfmt, "registered device", registration, content.RegisteredDevice)
+	}
+}
+
+func Test_AdmInstallation(t *testing.T) {
+	var nhub, mockClient = initTestItems()
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		if req.Method == putMethod {
+			body, _ := io.ReadAll(req.Body)
+			if !strings.Contains(string(body), `"platform":"adm"`) {
+				t.Errorf(errfmt, "installation body", "adm platform", string(body))
+			}
+			return nil, nil, nil
+		}
+		data, err := os.ReadFile("./fixtures/admInstallationResult.json")
+		return data, nil, err
+	}
+
+	_, installation, err := nhub.Installation(context.Background(), "adm-installation-sample-id")
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	expected := &Installation{
+		InstallationID: "adm-installation-sample-id",
+		ExpirationTime: &endOfEpoch,
+		Platform:       ADMPlatform,
+		PushChannel:    "amzn1.adm-registration.v3.sample",
+		Tags:           []string{"kindle"},
+	}
+	if !reflect.DeepEqual(installation, expected) {
+		t.Errorf(errfmt, "installation", expected, installation)
+	}
+
+	if err = nhub.Install(context.Background(), *installation); err != nil {
+		t.Errorf(errfmt, "install error", nil, err)
+	}
+}
+
+func Test_InstallRejectsUnknownPlatform(t *testing.T) {
+	var nhub, mockClient = initTestItems()
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		t.Errorf("unexpected request %s", req.URL)
+		return nil, nil, nil
+	}
+
+	for _, platform := range []InstallationPlatform{"", "gcm", "xiaomi"} {
+		err := nhub.Install(context.Background(), Installation{InstallationID: "id", Platform: platform, PushChannel: "x"})
+
+		var hubErr *NotificationHubError
+		if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeInvalidInstallation {
+			t.Errorf(errfmt, "error for platform '"+string(platform)+"'", ErrorCodeInvalidInstallation, err)
+		}
+	}
+}
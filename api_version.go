@@ -0,0 +1,209 @@
+package notificationhubs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operation types accepted by GetAPIVersionForOperation and WithOperationAPIVersion
+const (
+	OperationTypeSend         = "send"
+	OperationTypeRegistration = "registration"
+	OperationTypeInstallation = "installation"
+	OperationTypeTelemetry    = "telemetry"
+)
+
+// Features accepted by GetAPIVersionForOperation that need a later api-version than their operation type
+const (
+	// APIFeatureFcmV1 is sending to, registering or installing FCM v1 devices
+	APIFeatureFcmV1 = "fcmv1"
+)
+
+var (
+	// apiVersionPattern matches api-versions such as 2016-07 or 2020-06-01-preview
+	apiVersionPattern = regexp.MustCompile(`^\d{4}-\d{2}(-\d{2})?(-preview)?$`)
+
+	// minAPIVersions are the oldest api-versions supporting each operation type
+	minAPIVersions = map[string]string{
+		OperationTypeSend:         legacyAPIVersionValue,
+		OperationTypeRegistration: legacyAPIVersionValue,
+		OperationTypeInstallation: legacyAPIVersionValue,
+		// notification telemetry was added in 2016-07
+		OperationTypeTelemetry: telemetryAPIVersionValue,
+	}
+
+	// featureAPIVersions are the oldest api-versions supporting each feature
+	featureAPIVersions = map[string]string{
+		APIFeatureFcmV1: fcmV1APIVersionValue,
+	}
+)
+
+type (
+	// apiVersionContextKey stores the api-version of a single call
+	apiVersionContextKey struct{}
+
+	// apiFeatureContextKey stores the feature used by a single call
+	apiFeatureContextKey struct{}
+)
+
+// GetAPIVersionForOperation returns the api-version a hub without a chosen version uses for
+// operationType with features: DefaultAPIVersion, unless the operation type or one of the
+// features requires a later version. Unknown operation types and features get DefaultAPIVersion.
+func GetAPIVersionForOperation(operationType string, features ...string) string {
+	if required, _ := requiredAPIVersion(operationType, features); compareAPIVersions(required, DefaultAPIVersion) > 0 {
+		return required
+	}
+	return DefaultAPIVersion
+}
+
+// APIVersionForOperation returns the api-version h calls for operationType with features.
+// It reports the version chosen with WithAPIVersion or WithOperationAPIVersion, and fails
+// with ErrorCodeUnsupportedAPIVersion when that version does not support them.
+func (h *NotificationHub) APIVersionForOperation(operationType string, features ...string) (string, error) {
+	return h.config.Load().resolveAPIVersion(context.Background(), operationType, operationType, features)
+}
+
+// ContextWithAPIVersion returns a context making the hub call api-version v for
+// the operations run with it, overriding the hub and operation type versions
+func ContextWithAPIVersion(ctx context.Context, v string) context.Context {
+	return context.WithValue(ctx, apiVersionContextKey{}, v)
+}
+
+// ValidAPIVersion reports whether v is a well formed api-version such as 2016-07
+func ValidAPIVersion(v string) bool {
+	return apiVersionPattern.MatchString(v)
+}
+
+// apiType returns the operation type of op
+func (o operation) apiType() string {
+	switch o.class() {
+	case SendOperations:
+		return OperationTypeSend
+	case TelemetryOperations:
+		return OperationTypeTelemetry
+	}
+	switch o {
+	case opInstallation, opInstall, opUpdate, opUninstall:
+		return OperationTypeInstallation
+	}
+	return OperationTypeRegistration
+}
+
+// apiVersion returns the api-version of op, see resolveAPIVersion
+func (cfg *hubConfig) apiVersion(ctx context.Context, op operation) (string, error) {
+	var features []string
+	if feature, ok := ctx.Value(apiFeatureContextKey{}).(string); ok {
+		features = append(features, feature)
+	}
+	v, err := cfg.resolveAPIVersion(ctx, op.apiType(), string(op), features)
+	if err != nil {
+		err.(*NotificationHubError).Operation = string(op)
+	}
+	return v, err
+}
+
+// resolveAPIVersion returns the api-version of operationType with features, taken from the context,
+// the operation type override or the hub version in that order. Without any of them it is the
+// version GetAPIVersionForOperation picks. Chosen versions that are malformed or older than
+// required are reported as ErrorCodeUnsupportedAPIVersion, naming subject.
+func (cfg *hubConfig) resolveAPIVersion(ctx context.Context, operationType, subject string, features []string) (string, error) {
+	v, ok := ctx.Value(apiVersionContextKey{}).(string)
+	if !ok {
+		v, ok = cfg.operationAPIVersions[operationType]
+	}
+	if !ok && cfg.hubAPIVersion != "" {
+		v, ok = cfg.hubAPIVersion, true
+	}
+	if !ok {
+		return GetAPIVersionForOperation(operationType, features...), nil
+	}
+
+	if !ValidAPIVersion(v) {
+		return "", NewError(ErrorCodeUnsupportedAPIVersion, fmt.Sprintf("malformed api-version %q", v))
+	}
+	if required, feature := requiredAPIVersion(operationType, features); compareAPIVersions(v, required) < 0 {
+		if feature != "" {
+			subject += " for " + feature
+		}
+		return "", NewError(ErrorCodeUnsupportedAPIVersion, fmt.Sprintf("%s requires api-version %s or later, got %s", subject, required, v))
+	}
+	return v, nil
+}
+
+// requiredAPIVersion returns the oldest api-version supporting operationType with features,
+// and the feature requiring it, if any
+func requiredAPIVersion(operationType string, features []string) (version, feature string) {
+	version, ok := minAPIVersions[operationType]
+	if !ok {
+		version = legacyAPIVersionValue
+	}
+	for _, f := range features {
+		if v, ok := featureAPIVersions[f]; ok && compareAPIVersions(v, version) > 0 {
+			version, feature = v, f
+		}
+	}
+	return version, feature
+}
+
+// withAPIFeature returns a context making the operations run with it require the api-version of feature
+func withAPIFeature(ctx context.Context, feature string) context.Context {
+	if feature == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, apiFeatureContextKey{}, feature)
+}
+
+// apiFeature returns the feature sending notifications of format f requires
+func (f NotificationFormat) apiFeature() string {
+	if f == FcmV1Format {
+		return APIFeatureFcmV1
+	}
+	return ""
+}
+
+// apiFeature returns the feature registering templates for platform p requires
+func (p TargetPlatform) apiFeature() string {
+	if p == FcmV1Platform || p == FcmV1TemplatePlatform {
+		return APIFeatureFcmV1
+	}
+	return ""
+}
+
+// apiFeature returns the feature installing devices of platform p requires
+func (p InstallationPlatform) apiFeature() string {
+	if p == FCMV1Platform {
+		return APIFeatureFcmV1
+	}
+	return ""
+}
+
+// compareAPIVersions compares two well formed api-versions by date,
+// a preview version sorts before the release of the same date
+func compareAPIVersions(a, b string) int {
+	aDate, aPreview := splitAPIVersion(a)
+	bDate, bPreview := splitAPIVersion(b)
+	switch {
+	case aDate < bDate:
+		return -1
+	case aDate > bDate:
+		return 1
+	case aPreview == bPreview:
+		return 0
+	case aPreview:
+		return -1
+	}
+	return 1
+}
+
+// splitAPIVersion returns the date of v, with a missing day as 00, and whether it is a preview
+func splitAPIVersion(v string) (date string, preview bool) {
+	if strings.HasSuffix(v, "-preview") {
+		v, preview = strings.TrimSuffix(v, "-preview"), true
+	}
+	if len(v) == len("2006-01") {
+		v += "-00"
+	}
+	return v, preview
+}
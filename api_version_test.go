@@ -1,6 +1,10 @@
 package notificationhubs_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
 
 	. "github.com/koreset/azure-notificationhubs-sdk-go"
@@ -10,35 +14,49 @@ func TestGetAPIVersionForOperation(t *testing.T) {
 	testCases := []struct {
 		name          string
 		operationType string
+		features      []string
 		expected      string
 	}{
 		{
 			name:          "Send operations use latest API 2016-07",
-			operationType: "send",
+			operationType: OperationTypeSend,
 			expected:      "2016-07",
 		},
 		{
 			name:          "Registration operations use latest API 2016-07",
-			operationType: "registration",
+			operationType: OperationTypeRegistration,
 			expected:      "2016-07",
 		},
 		{
-			name:          "Telemetry operations use latest API 2016-07",
-			operationType: "telemetry",
+			name:          "Installation operations use latest API 2016-07",
+			operationType: OperationTypeInstallation,
 			expected:      "2016-07",
 		},
 		{
-			name:          "Message telemetry operations use latest API 2016-07",
-			operationType: "message-telemetry",
+			name:          "Telemetry operations use latest API 2016-07",
+			operationType: OperationTypeTelemetry,
 			expected:      "2016-07",
 		},
 		{
-			name:          "PNS error details operations use latest API 2016-07",
-			operationType: "pns-error-details",
+			name:          "FCM v1 sends require 2020-06",
+			operationType: OperationTypeSend,
+			features:      []string{APIFeatureFcmV1},
+			expected:      "2020-06",
+		},
+		{
+			name:          "FCM v1 installations require 2020-06",
+			operationType: OperationTypeInstallation,
+			features:      []string{APIFeatureFcmV1},
+			expected:      "2020-06",
+		},
+		{
+			name:          "Unknown features use latest API 2016-07",
+			operationType: OperationTypeSend,
+			features:      []string{"unknown-feature"},
 			expected:      "2016-07",
 		},
 		{
-			name:          "All operations use latest API 2016-07",
+			name:          "Unknown operations use latest API 2016-07",
 			operationType: "unknown-operation",
 			expected:      "2016-07",
 		},
@@ -46,9 +64,9 @@ func TestGetAPIVersionForOperation(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := GetAPIVersionForOperation(tc.operationType)
+			result := GetAPIVersionForOperation(tc.operationType, tc.features...)
 			if result != tc.expected {
-				t.Errorf("GetAPIVersionForOperation(%q) = %q; want %q", tc.operationType, result, tc.expected)
+				t.Errorf("GetAPIVersionForOperation(%q, %q) = %q; want %q", tc.operationType, tc.features, result, tc.expected)
 			}
 		})
 	}
@@ -67,3 +85,210 @@ func TestAPIVersionConstants(t *testing.T) {
 		t.Errorf("LegacyAPIVersion = %q; want %q", LegacyAPIVersion, "2015-01")
 	}
 }
+
+func TestAPIVersionSelection(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      []HubOption
+		ctx       context.Context
+		send      string
+		telemetry string
+		code      ErrorCode
+	}{
+		{
+			name:      "Default hub uses 2016-07",
+			send:      "2016-07",
+			telemetry: "2016-07",
+		},
+		{
+			name:      "Hub version applies to all operations",
+			opts:      []HubOption{WithAPIVersion("2020-06")},
+			send:      "2020-06",
+			telemetry: "2020-06",
+		},
+		{
+			name:      "Operation type version overrides the hub version",
+			opts:      []HubOption{WithAPIVersion(LegacyAPIVersion), WithOperationAPIVersion(OperationTypeTelemetry, "2016-07")},
+			send:      "2015-01",
+			telemetry: "2016-07",
+		},
+		{
+			name:      "Context version overrides the hub and operation type versions",
+			opts:      []HubOption{WithOperationAPIVersion(OperationTypeSend, "2016-07")},
+			ctx:       ContextWithAPIVersion(context.Background(), "2023-10-01-preview"),
+			send:      "2023-10-01-preview",
+			telemetry: "2023-10-01-preview",
+		},
+		{
+			name: "Legacy hub cannot read telemetry",
+			opts: []HubOption{WithAPIVersion(LegacyAPIVersion)},
+			send: "2015-01",
+			code: ErrorCodeUnsupportedAPIVersion,
+		},
+		{
+			name: "Malformed versions are rejected",
+			opts: []HubOption{WithAPIVersion("latest")},
+			code: ErrorCodeUnsupportedAPIVersion,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mockClient      = &mockHubHTTPClient{}
+				nhub            = NewNotificationHub(connectionString, hubPath, append(tc.opts, WithHTTPClient(mockClient))...)
+				notification, _ = NewNotification(Template, []byte("{}"))
+				ctx             = tc.ctx
+				versions        []string
+			)
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+				versions = append(versions, req.URL.Query().Get(apiVersionParam))
+				return []byte("<NotificationDetails></NotificationDetails>"), &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+			}
+
+			_, _, sendErr := nhub.Send(ctx, notification, nil)
+			_, _, telemetryErr := nhub.NotificationDetails(ctx, "id")
+
+			var want []string
+			for _, v := range []string{tc.send, tc.telemetry} {
+				if v != "" {
+					want = append(want, v)
+				}
+			}
+			if strings.Join(versions, ",") != strings.Join(want, ",") {
+				t.Errorf(errfmt, "api-versions", want, versions)
+			}
+
+			var hubErr *NotificationHubError
+			switch {
+			case tc.code == "" && (sendErr != nil || telemetryErr != nil):
+				t.Errorf(errfmt, "errors", nil, []error{sendErr, telemetryErr})
+			case tc.code != "" && (!errors.As(telemetryErr, &hubErr) || hubErr.Code != tc.code):
+				t.Errorf(errfmt, "telemetry error", tc.code, telemetryErr)
+			}
+		})
+	}
+}
+
+func TestAPIVersionErrorNamesTheRequirement(t *testing.T) {
+	var (
+		mockClient = &mockHubHTTPClient{}
+		nhub       = NewNotificationHub(connectionString, hubPath, WithHTTPClient(mockClient), WithAPIVersion(LegacyAPIVersion))
+	)
+
+	_, _, err := nhub.NotificationDetails(context.Background(), "id")
+
+	if err == nil || !strings.Contains(err.Error(), "NotificationDetails requires api-version 2016-07 or later, got 2015-01") {
+		t.Errorf(errfmt, "error", "version requirement", err)
+	}
+}
+
+func TestAPIVersionFeatureGates(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []HubOption
+		version string
+		message string
+	}{
+		{
+			name:    "Hubs without a chosen version use the FCM v1 version",
+			version: "2020-06",
+		},
+		{
+			name:    "A chosen version supporting FCM v1 is used",
+			opts:    []HubOption{WithAPIVersion("2023-10-01-preview")},
+			version: "2023-10-01-preview",
+		},
+		{
+			name:    "Operation type versions apply to FCM v1",
+			opts:    []HubOption{WithAPIVersion(LegacyAPIVersion), WithOperationAPIVersion(OperationTypeSend, "2020-06")},
+			version: "2020-06",
+		},
+		{
+			name:    "A chosen version older than FCM v1 is rejected",
+			opts:    []HubOption{WithAPIVersion("2016-07")},
+			message: "Send for fcmv1 requires api-version 2020-06 or later, got 2016-07",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mockClient      = &mockHubHTTPClient{}
+				nhub            = NewNotificationHub(connectionString, hubPath, append(tc.opts, WithHTTPClient(mockClient))...)
+				notification, _ = NewNotification(FcmV1Format, []byte(`{"message":{}}`))
+				versions        []string
+			)
+			mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+				versions = append(versions, req.URL.Query().Get(apiVersionParam))
+				return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+			}
+
+			_, _, err := nhub.Send(context.Background(), notification, nil)
+
+			if tc.message != "" {
+				var hubErr *NotificationHubError
+				if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeUnsupportedAPIVersion || !strings.Contains(err.Error(), tc.message) {
+					t.Errorf(errfmt, "error", tc.message, err)
+				}
+				if len(versions) != 0 {
+					t.Errorf(errfmt, "requests", 0, versions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(errfmt, "error", nil, err)
+			}
+			if len(versions) != 1 || versions[0] != tc.version {
+				t.Errorf(errfmt, "api-versions", tc.version, versions)
+			}
+		})
+	}
+}
+
+func TestHubAPIVersionForOperation(t *testing.T) {
+	nhub := NewNotificationHub(connectionString, hubPath,
+		WithAPIVersion(LegacyAPIVersion),
+		WithOperationAPIVersion(OperationTypeRegistration, "2020-06"),
+	)
+
+	for _, tc := range []struct {
+		operationType string
+		features      []string
+		expected      string
+		code          ErrorCode
+	}{
+		{operationType: OperationTypeSend, expected: "2015-01"},
+		{operationType: OperationTypeRegistration, features: []string{APIFeatureFcmV1}, expected: "2020-06"},
+		{operationType: OperationTypeInstallation, features: []string{APIFeatureFcmV1}, code: ErrorCodeUnsupportedAPIVersion},
+		{operationType: OperationTypeTelemetry, code: ErrorCodeUnsupportedAPIVersion},
+	} {
+		version, err := nhub.APIVersionForOperation(tc.operationType, tc.features...)
+
+		var hubErr *NotificationHubError
+		switch {
+		case tc.code != "" && (!errors.As(err, &hubErr) || hubErr.Code != tc.code):
+			t.Errorf(errfmt, tc.operationType+" error", tc.code, err)
+		case tc.code == "" && (err != nil || version != tc.expected):
+			t.Errorf(errfmt, tc.operationType+" api-version", tc.expected, version)
+		}
+	}
+}
+
+func TestValidAPIVersion(t *testing.T) {
+	for v, want := range map[string]bool{
+		"2015-01":            true,
+		"2020-06":            true,
+		"2023-10-01-preview": true,
+		"2016":               false,
+		"latest":             false,
+		"":                   false,
+	} {
+		if got := ValidAPIVersion(v); got != want {
+			t.Errorf("ValidAPIVersion(%q) = %v; want %v", v, got, want)
+		}
+	}
+}
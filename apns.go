@@ -0,0 +1,246 @@
+package notificationhubs
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ApnsMaxPayloadSize is the largest payload APNs accepts, in bytes
+const ApnsMaxPayloadSize = 4096
+
+// Interruption levels of an APNs notification
+const (
+	ApnsInterruptionPassive       ApnsInterruptionLevel = "passive"
+	ApnsInterruptionActive        ApnsInterruptionLevel = "active"
+	ApnsInterruptionTimeSensitive ApnsInterruptionLevel = "time-sensitive"
+	ApnsInterruptionCritical      ApnsInterruptionLevel = "critical"
+)
+
+type (
+	// ApnsPayload is a typed APNs notification payload
+	ApnsPayload struct {
+		Aps ApnsAps
+		// Custom holds custom top-level keys sent next to aps
+		Custom map[string]interface{}
+	}
+
+	// ApnsAps is the aps dictionary of an APNs payload
+	ApnsAps struct {
+		Alert *ApnsAlert
+		// Badge sets the app badge, a badge of 0 removes it
+		Badge *int
+		Sound *ApnsSound
+		// ThreadID groups notifications in the notification center
+		ThreadID string
+		// Category selects the actions shown with the notification
+		Category string
+		// ContentAvailable makes the notification a background notification
+		ContentAvailable bool
+		// MutableContent lets a notification service extension modify the alert
+		MutableContent bool
+		// InterruptionLevel sets the importance of the notification, iOS 15 and later
+		InterruptionLevel ApnsInterruptionLevel
+		// RelevanceScore sorts the notification summary, from 0 to 1
+		RelevanceScore *float64
+		// FilterCriteria selects the Focus filter the notification applies to
+		FilterCriteria string
+	}
+
+	// ApnsAlert is the alert of an APNs notification
+	ApnsAlert struct {
+		Title           string   `json:"title,omitempty"`
+		Subtitle        string   `json:"subtitle,omitempty"`
+		Body            string   `json:"body,omitempty"`
+		TitleLocKey     string   `json:"title-loc-key,omitempty"`
+		TitleLocArgs    []string `json:"title-loc-args,omitempty"`
+		SubtitleLocKey  string   `json:"subtitle-loc-key,omitempty"`
+		SubtitleLocArgs []string `json:"subtitle-loc-args,omitempty"`
+		LocKey          string   `json:"loc-key,omitempty"`
+		LocArgs         []string `json:"loc-args,omitempty"`
+		LaunchImage     string   `json:"launch-image,omitempty"`
+	}
+
+	// ApnsSound is the sound of an APNs notification.
+	// A sound that is not critical is sent as the plain sound name.
+	ApnsSound struct {
+		// Name is a sound file of the app or "default"
+		Name string
+		// Critical plays the sound even in silent mode, it requires an entitlement
+		Critical bool
+		// Volume of a critical sound, from 0 to 1. It is omitted when 0, so the sound plays at full volume.
+		Volume float64
+	}
+
+	// ApnsInterruptionLevel is the importance of an APNs notification
+	ApnsInterruptionLevel string
+
+	// apsJSON is the wire format of ApnsAps
+	apsJSON struct {
+		Alert             *ApnsAlert            `json:"alert,omitempty"`
+		Badge             *int                  `json:"badge,omitempty"`
+		Sound             *ApnsSound            `json:"sound,omitempty"`
+		ThreadID          string                `json:"thread-id,omitempty"`
+		Category          string                `json:"category,omitempty"`
+		ContentAvailable  int                   `json:"content-available,omitempty"`
+		MutableContent    int                   `json:"mutable-content,omitempty"`
+		InterruptionLevel ApnsInterruptionLevel `json:"interruption-level,omitempty"`
+		RelevanceScore    *float64              `json:"relevance-score,omitempty"`
+		FilterCriteria    string                `json:"filter-criteria,omitempty"`
+	}
+)
+
+// NewApnsAlert returns a payload showing an alert with title and body
+func NewApnsAlert(title, body string) *ApnsPayload {
+	return &ApnsPayload{Aps: ApnsAps{Alert: &ApnsAlert{Title: title, Body: body}}}
+}
+
+// NewApnsBackground returns a payload waking the app in the background
+func NewApnsBackground() *ApnsPayload {
+	return &ApnsPayload{Aps: ApnsAps{ContentAvailable: true}}
+}
+
+// Build validates the payload and returns it as an AppleFormat notification
+func (p *ApnsPayload) Build() (*Notification, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrorCodeInvalidPayload, "cannot encode APNs payload", err)
+	}
+	if len(payload) > ApnsMaxPayloadSize {
+		return nil, NewError(ErrorCodePayloadTooLarge, fmt.Sprintf("APNs payload is %d bytes, the limit is %d", len(payload), ApnsMaxPayloadSize))
+	}
+	return newNotification(AppleFormat, payload)
+}
+
+// Validate reports the first invalid field or field combination as a *ValidationError
+func (p *ApnsPayload) Validate() error {
+	if _, ok := p.Custom["aps"]; ok {
+		return NewValidationError("Custom", "must not contain the reserved key aps", "aps")
+	}
+	return p.Aps.validate()
+}
+
+// validateOverride checks the payload as an override merged into another notification,
+// which may leave aps empty or rely on the alert of the notification it overrides
+func (p *ApnsPayload) validateOverride() error {
+	if _, ok := p.Custom["aps"]; ok {
+		return NewValidationError("Custom", "must not contain the reserved key aps", "aps")
+	}
+	return p.Aps.validateFields()
+}
+
+// MarshalJSON implements json.Marshaler, adding the custom keys next to aps
+func (p ApnsPayload) MarshalJSON() ([]byte, error) {
+	payload := make(map[string]interface{}, len(p.Custom)+1)
+	for key, value := range p.Custom {
+		payload[key] = value
+	}
+	payload["aps"] = p.Aps
+	return json.Marshal(payload)
+}
+
+// MarshalJSON implements json.Marshaler
+func (a ApnsAps) MarshalJSON() ([]byte, error) {
+	aps := apsJSON{
+		Alert:             a.Alert,
+		Badge:             a.Badge,
+		Sound:             a.Sound,
+		ThreadID:          a.ThreadID,
+		Category:          a.Category,
+		InterruptionLevel: a.InterruptionLevel,
+		RelevanceScore:    a.RelevanceScore,
+		FilterCriteria:    a.FilterCriteria,
+	}
+	if a.ContentAvailable {
+		aps.ContentAvailable = 1
+	}
+	if a.MutableContent {
+		aps.MutableContent = 1
+	}
+	return json.Marshal(aps)
+}
+
+// MarshalJSON implements json.Marshaler, writing a sound that is not critical as its name.
+// A critical sound without a volume is written without one, since APNs plays volume 0 silently.
+func (s ApnsSound) MarshalJSON() ([]byte, error) {
+	if !s.Critical {
+		return json.Marshal(s.Name)
+	}
+	return json.Marshal(struct {
+		Critical int     `json:"critical"`
+		Name     string  `json:"name"`
+		Volume   float64 `json:"volume,omitempty"`
+	}{1, s.Name, s.Volume})
+}
+
+// validate checks the aps fields and their combinations
+func (a *ApnsAps) validate() error {
+	if a.Alert == nil && a.Badge == nil && a.Sound == nil && !a.ContentAvailable {
+		return NewValidationError("aps", "needs an alert, badge, sound or content-available", nil)
+	}
+	if a.MutableContent && a.Alert == nil {
+		return NewValidationError("aps.mutable-content", "requires an alert", true)
+	}
+	return a.validateFields()
+}
+
+// validateFields checks the fields that are set, without requiring any of them
+func (a *ApnsAps) validateFields() error {
+	if a.Badge != nil && *a.Badge < 0 {
+		return NewValidationError("aps.badge", "must not be negative", *a.Badge)
+	}
+
+	if a.Alert != nil {
+		if err := a.Alert.validate(); err != nil {
+			return err
+		}
+	}
+	if a.Sound != nil {
+		if err := a.Sound.validate(); err != nil {
+			return err
+		}
+		if a.Sound.Critical && a.InterruptionLevel != "" && a.InterruptionLevel != ApnsInterruptionCritical {
+			return NewValidationError("aps.interruption-level", "must be critical with a critical sound", a.InterruptionLevel)
+		}
+	}
+
+	switch a.InterruptionLevel {
+	case "", ApnsInterruptionPassive, ApnsInterruptionActive, ApnsInterruptionTimeSensitive, ApnsInterruptionCritical:
+	default:
+		return NewValidationError("aps.interruption-level", "is not a known interruption level", a.InterruptionLevel)
+	}
+	if a.RelevanceScore != nil && (*a.RelevanceScore < 0 || *a.RelevanceScore > 1) {
+		return NewValidationError("aps.relevance-score", "must be between 0 and 1", *a.RelevanceScore)
+	}
+	return nil
+}
+
+// validate checks that localization arguments come with their key
+func (a *ApnsAlert) validate() error {
+	switch {
+	case a.Title == "" && a.Body == "" && a.TitleLocKey == "" && a.LocKey == "":
+		return NewValidationError("aps.alert", "needs a title, body, title-loc-key or loc-key", nil)
+	case len(a.TitleLocArgs) > 0 && a.TitleLocKey == "":
+		return NewValidationError("aps.alert.title-loc-args", "requires title-loc-key", a.TitleLocArgs)
+	case len(a.SubtitleLocArgs) > 0 && a.SubtitleLocKey == "":
+		return NewValidationError("aps.alert.subtitle-loc-args", "requires subtitle-loc-key", a.SubtitleLocArgs)
+	case len(a.LocArgs) > 0 && a.LocKey == "":
+		return NewValidationError("aps.alert.loc-args", "requires loc-key", a.LocArgs)
+	}
+	return nil
+}
+
+// validate checks the sound name and the volume of critical sounds
+func (s *ApnsSound) validate() error {
+	switch {
+	case s.Name == "":
+		return NewValidationError("aps.sound", "needs a name", nil)
+	case !s.Critical && s.Volume != 0:
+		return NewValidationError("aps.sound.volume", "is only supported by critical sounds", s.Volume)
+	case s.Volume < 0 || s.Volume > 1:
+		return NewValidationError("aps.sound.volume", "must be between 0 and 1", s.Volume)
+	}
+	return nil
+}
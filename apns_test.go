@@ -0,0 +1,153 @@
+package notificationhubs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func Test_ApnsPayloadNotification(t *testing.T) {
+	var (
+		badge   = 3
+		score   = 0.75
+		payload = &ApnsPayload{
+			Aps: ApnsAps{
+				Alert: &ApnsAlert{
+					Title:    "Order shipped",
+					Subtitle: "Order 42",
+					LocKey:   "SHIPPED_BODY",
+					LocArgs:  []string{"42"},
+				},
+				Badge:             &badge,
+				Sound:             &ApnsSound{Name: "alarm.caf", Critical: true, Volume: 0.5},
+				ThreadID:          "orders",
+				Category:          "ORDER",
+				MutableContent:    true,
+				InterruptionLevel: ApnsInterruptionCritical,
+				RelevanceScore:    &score,
+				FilterCriteria:    "work",
+			},
+			Custom: map[string]interface{}{"orderId": 42},
+		}
+	)
+
+	notification, err := payload.Build()
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if notification.Format != AppleFormat {
+		t.Errorf(errfmt, "format", AppleFormat, notification.Format)
+	}
+
+	var got, want map[string]interface{}
+	_ = json.Unmarshal(notification.Payload, &got)
+	_ = json.Unmarshal([]byte(`{
+		"aps": {
+			"alert": {"title": "Order shipped", "subtitle": "Order 42", "loc-key": "SHIPPED_BODY", "loc-args": ["42"]},
+			"badge": 3,
+			"sound": {"critical": 1, "name": "alarm.caf", "volume": 0.5},
+			"thread-id": "orders",
+			"category": "ORDER",
+			"mutable-content": 1,
+			"interruption-level": "critical",
+			"relevance-score": 0.75,
+			"filter-criteria": "work"
+		},
+		"orderId": 42
+	}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(errfmt, "payload", want, string(notification.Payload))
+	}
+}
+
+func Test_ApnsPayloadShortcuts(t *testing.T) {
+	alert, err := NewApnsAlert("Hello", "World").Build()
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if string(alert.Payload) != `{"aps":{"alert":{"title":"Hello","body":"World"}}}` {
+		t.Errorf(errfmt, "alert payload", "title and body", string(alert.Payload))
+	}
+
+	background, err := NewApnsBackground().Build()
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if string(background.Payload) != `{"aps":{"content-available":1}}` {
+		t.Errorf(errfmt, "background payload", "content-available", string(background.Payload))
+	}
+
+	wake, err := (&ApnsPayload{Aps: ApnsAps{Alert: &ApnsAlert{Body: "hi"}, ContentAvailable: true}}).Build()
+	if err != nil {
+		t.Fatalf(errfmt, "alert with content-available error", nil, err)
+	}
+	if string(wake.Payload) != `{"aps":{"alert":{"body":"hi"},"content-available":1}}` {
+		t.Errorf(errfmt, "alert with content-available payload", "alert and content-available", string(wake.Payload))
+	}
+
+	sound, _ := (&ApnsPayload{Aps: ApnsAps{Sound: &ApnsSound{Name: "default"}}}).Build()
+	if string(sound.Payload) != `{"aps":{"sound":"default"}}` {
+		t.Errorf(errfmt, "sound payload", "plain sound name", string(sound.Payload))
+	}
+
+	critical, _ := (&ApnsPayload{Aps: ApnsAps{Sound: &ApnsSound{Name: "default", Critical: true}}}).Build()
+	if string(critical.Payload) != `{"aps":{"sound":{"critical":1,"name":"default"}}}` {
+		t.Errorf(errfmt, "critical sound payload", "no volume", string(critical.Payload))
+	}
+}
+
+func Test_ApnsPayloadValidation(t *testing.T) {
+	var (
+		badge    = -1
+		score    = 1.5
+		alert    = &ApnsAlert{Body: "hi"}
+		testCase = []struct {
+			name  string
+			aps   ApnsAps
+			field string
+		}{
+			{name: "empty aps", aps: ApnsAps{}, field: "aps"},
+			{name: "mutable content without alert", aps: ApnsAps{MutableContent: true, Badge: new(int)}, field: "aps.mutable-content"},
+			{name: "negative badge", aps: ApnsAps{Badge: &badge}, field: "aps.badge"},
+			{name: "empty alert", aps: ApnsAps{Alert: &ApnsAlert{Subtitle: "only"}}, field: "aps.alert"},
+			{name: "loc args without key", aps: ApnsAps{Alert: &ApnsAlert{Body: "x", LocArgs: []string{"1"}}}, field: "aps.alert.loc-args"},
+			{name: "title loc args without key", aps: ApnsAps{Alert: &ApnsAlert{Body: "x", TitleLocArgs: []string{"1"}}}, field: "aps.alert.title-loc-args"},
+			{name: "unnamed sound", aps: ApnsAps{Sound: &ApnsSound{}}, field: "aps.sound"},
+			{name: "volume of regular sound", aps: ApnsAps{Sound: &ApnsSound{Name: "default", Volume: 0.5}}, field: "aps.sound.volume"},
+			{name: "critical volume too loud", aps: ApnsAps{Sound: &ApnsSound{Name: "default", Critical: true, Volume: 2}}, field: "aps.sound.volume"},
+			{name: "critical sound passive", aps: ApnsAps{Sound: &ApnsSound{Name: "default", Critical: true}, InterruptionLevel: ApnsInterruptionPassive}, field: "aps.interruption-level"},
+			{name: "unknown interruption level", aps: ApnsAps{Alert: alert, InterruptionLevel: "urgent"}, field: "aps.interruption-level"},
+			{name: "relevance score out of range", aps: ApnsAps{Alert: alert, RelevanceScore: &score}, field: "aps.relevance-score"},
+		}
+	)
+
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&ApnsPayload{Aps: tc.aps}).Build()
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tc.field {
+				t.Errorf(errfmt, "validation error", tc.field, err)
+			}
+		})
+	}
+
+	_, err := (&ApnsPayload{Aps: ApnsAps{Alert: alert}, Custom: map[string]interface{}{"aps": 1}}).Build()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "Custom" {
+		t.Errorf(errfmt, "validation error", "Custom", err)
+	}
+}
+
+func Test_ApnsPayloadTooLarge(t *testing.T) {
+	_, err := NewApnsAlert("big", strings.Repeat("x", ApnsMaxPayloadSize)).Build()
+
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodePayloadTooLarge {
+		t.Errorf(errfmt, "error", ErrorCodePayloadTooLarge, err)
+	}
+}
@@ -0,0 +1,79 @@
+package notificationhubs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// BaiduMaxPayloadSize is the largest message Baidu Cloud Push accepts, in bytes
+const BaiduMaxPayloadSize = 4096
+
+// Actions taken when a Baidu notification is clicked
+const (
+	BaiduOpenApp BaiduOpenType = 0
+	BaiduOpenURL BaiduOpenType = 1
+	BaiduOpenPkg BaiduOpenType = 2
+)
+
+type (
+	// BaiduMessage is a typed Baidu Cloud Push Android notification for BaiduFormat notifications
+	BaiduMessage struct {
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description"`
+		// NotificationBuilderID selects a notification style registered by the app
+		NotificationBuilderID int `json:"notification_builder_id,omitempty"`
+		// OpenType is the action taken when the notification is clicked
+		OpenType BaiduOpenType `json:"open_type,omitempty"`
+		// URL is opened by BaiduOpenURL, it must be an http or https URL
+		URL string `json:"url,omitempty"`
+		// PkgContent is the intent opened by BaiduOpenPkg
+		PkgContent string `json:"pkg_content,omitempty"`
+		// CustomContent is delivered to the app with the notification
+		CustomContent map[string]interface{} `json:"custom_content,omitempty"`
+	}
+
+	// BaiduOpenType is the open_type of a Baidu notification
+	BaiduOpenType int
+)
+
+// NewBaiduMessage returns a notification showing title and description
+func NewBaiduMessage(title, description string) *BaiduMessage {
+	return &BaiduMessage{Title: title, Description: description}
+}
+
+// Build validates the message and returns it as a BaiduFormat notification
+func (m *BaiduMessage) Build() (*Notification, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrorCodeInvalidPayload, "cannot encode Baidu message", err)
+	}
+	if len(payload) > BaiduMaxPayloadSize {
+		return nil, NewError(ErrorCodePayloadTooLarge, fmt.Sprintf("Baidu message is %d bytes, the limit is %d", len(payload), BaiduMaxPayloadSize))
+	}
+	return newNotification(BaiduFormat, payload)
+}
+
+// Validate reports the first invalid field as a *ValidationError
+func (m *BaiduMessage) Validate() error {
+	if m.Description == "" {
+		return NewValidationError("description", "is required", nil)
+	}
+	switch m.OpenType {
+	case BaiduOpenApp:
+	case BaiduOpenURL:
+		if link, err := url.Parse(m.URL); err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return NewValidationError("url", "must be an http or https URL", m.URL)
+		}
+	case BaiduOpenPkg:
+		if m.PkgContent == "" {
+			return NewValidationError("pkg_content", "is required to open an intent", nil)
+		}
+	default:
+		return NewValidationError("open_type", "must be 0, 1 or 2", m.OpenType)
+	}
+	return nil
+}
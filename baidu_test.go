@@ -0,0 +1,198 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func Test_BaiduMessage(t *testing.T) {
+	message := NewBaiduMessage("Order shipped", "Your order is on its way")
+	message.OpenType = BaiduOpenURL
+	message.URL = "https://example.com/orders/42"
+	message.CustomContent = map[string]interface{}{"orderId": "42"}
+
+	notification, err := message.Build()
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if notification.Format != BaiduFormat {
+		t.Errorf(errfmt, "format", BaiduFormat, notification.Format)
+	}
+	want := `{"title":"Order shipped","description":"Your order is on its way","open_type":1,"url":"https://example.com/orders/42","custom_content":{"orderId":"42"}}`
+	if string(notification.Payload) != want {
+		t.Errorf(errfmt, "payload", want, string(notification.Payload))
+	}
+}
+
+func Test_BaiduMessageValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		message BaiduMessage
+		field   string
+	}{
+		{name: "no description", message: BaiduMessage{Title: "t"}, field: "description"},
+		{name: "url without link", message: BaiduMessage{Description: "d", OpenType: BaiduOpenURL}, field: "url"},
+		{name: "url not http", message: BaiduMessage{Description: "d", OpenType: BaiduOpenURL, URL: "ftp://example.com"}, field: "url"},
+		{name: "intent without content", message: BaiduMessage{Description: "d", OpenType: BaiduOpenPkg}, field: "pkg_content"},
+		{name: "unknown open type", message: BaiduMessage{Description: "d", OpenType: 3}, field: "open_type"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.message.Build()
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != test.field {
+				t.Errorf(errfmt, "validation error", test.field, err)
+			}
+		})
+	}
+
+	_, err := NewBaiduMessage("t", strings.Repeat("x", BaiduMaxPayloadSize)).Build()
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodePayloadTooLarge {
+		t.Errorf(errfmt, "error", ErrorCodePayloadTooLarge, err)
+	}
+}
+
+func Test_RegisterBaidu(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		registration     = Registration{
+			Tags:               "china,tag2",
+			DeviceID:           "3979461230281947561",
+			BaiduUserID:        "691340911982736548",
+			NotificationFormat: BaiduFormat,
+		}
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if !strings.Contains(string(body), "<BaiduRegistrationDescription") ||
+			!strings.Contains(string(body), "<BaiduUserId>691340911982736548</BaiduUserId>") ||
+			!strings.Contains(string(body), "<BaiduChannelId>3979461230281947561</BaiduChannelId>") {
+			t.Errorf(errfmt, "registration body", "BaiduRegistrationDescription", string(body))
+		}
+		data, err := os.ReadFile("./fixtures/baiduRegistrationResult.xml")
+		return data, nil, err
+	}
+
+	_, result, err := nhub.Register(context.Background(), registration)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	expected := &RegistrationContent{
+		Format: BaiduFormat,
+		Target: BaiduPlatform,
+		RegisteredDevice: &RegisteredDevice{
+			DeviceID:       "3979461230281947561",
+			BaiduUserID:    "691340911982736548",
+			ETag:           "1",
+			ExpirationTime: &endOfEpoch,
+			RegistrationID: "6271498734016873721-2283461940582391843-4",
+			Tags:           []string{"china", "tag2"},
+		},
+	}
+	if !reflect.DeepEqual(result.RegistrationContent, expected) {
+		t.Errorf(errfmt, "registration content", expected, result.RegistrationContent)
+	}
+}
+
+func Test_RegisterBaiduTemplate(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		registration     = TemplateRegistration{
+			Tags:        "china",
+			DeviceID:    "3979461230281947561",
+			BaiduUserID: "691340911982736548",
+			Template:    `{"title":"$(title)","description":"$(message)"}`,
+			Platform:    BaiduPlatform,
+		}
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if !strings.Contains(string(body), "<BaiduTemplateRegistrationDescription") || !strings.Contains(string(body), `<![CDATA[{"title":"$(title)","description":"$(message)"}]]>`) {
+			t.Errorf(errfmt, "registration body", "BaiduTemplateRegistrationDescription", string(body))
+		}
+		data, err := os.ReadFile("./fixtures/baiduTemplateRegistrationResult.xml")
+		return data, nil, err
+	}
+
+	_, result, err := nhub.RegisterWithTemplate(context.Background(), registration)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	content := result.RegistrationContent
+	if content.Format != Template || content.Target != BaiduTemplatePlatform {
+		t.Errorf(errfmt, "format and target", "template baidutemplate", content)
+	}
+	device := content.RegisteredDevice
+	if device.DeviceID != registration.DeviceID || device.BaiduUserID != registration.BaiduUserID || device.Template != registration.Template {
+		t.Errorf(errfmt, "registered device", registration, device)
+	}
+}
+
+func Test_RegisterBaiduRequiresUserID(t *testing.T) {
+	var nhub, mockClient = initTestItems()
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		t.Errorf("unexpected request %s", req.URL)
+		return nil, nil, nil
+	}
+
+	_, _, err := nhub.Register(context.Background(), Registration{DeviceID: "channel", NotificationFormat: BaiduFormat})
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeInvalidRegistration {
+		t.Errorf(errfmt, "Register error", ErrorCodeInvalidRegistration, err)
+	}
+
+	_, _, err = nhub.RegisterWithTemplate(context.Background(), TemplateRegistration{DeviceID: "channel", Platform: BaiduPlatform, Template: "{}"})
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeInvalidRegistration {
+		t.Errorf(errfmt, "RegisterWithTemplate error", ErrorCodeInvalidRegistration, err)
+	}
+}
+
+func Test_BaiduInstallation(t *testing.T) {
+	var nhub, mockClient = initTestItems()
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		if req.Method == putMethod {
+			body, _ := io.ReadAll(req.Body)
+			if !strings.Contains(string(body), `"platform":"baidu"`) {
+				t.Errorf(errfmt, "installation body", "baidu platform", string(body))
+			}
+			return nil, nil, nil
+		}
+		data, err := os.ReadFile("./fixtures/baiduInstallationResult.json")
+		return data, nil, err
+	}
+
+	_, installation, err := nhub.Installation(context.Background(), "baidu-installation-sample-id")
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	expected := &Installation{
+		InstallationID: "baidu-installation-sample-id",
+		ExpirationTime: &endOfEpoch,
+		Platform:       BAIDUPlatform,
+		PushChannel:    "691340911982736548-3979461230281947561",
+		Tags:           []string{"china"},
+	}
+	if !reflect.DeepEqual(installation, expected) {
+		t.Errorf(errfmt, "installation", expected, installation)
+	}
+
+	if err = nhub.Install(context.Background(), *installation); err != nil {
+		t.Errorf(errfmt, "install error", nil, err)
+	}
+}
@@ -0,0 +1,213 @@
+package notificationhubs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all requests with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through
+	CircuitHalfOpen
+)
+
+// ErrCircuitOpen is returned while the circuit breaker rejects requests.
+// Use errors.Is(err, ErrCircuitOpen) to detect it.
+var ErrCircuitOpen = NewError(ErrorCodeCircuitOpen, "circuit breaker is open")
+
+// String returns the CircuitState string representation
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerSettings configures a CircuitBreaker
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit, 5 by default
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing the hub, 30 seconds by default
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the number of successful probes closing the circuit again, 1 by default
+	HalfOpenMaxRequests int
+	// OnStateChange is called after every state transition
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker stops sending requests to a hub that keeps failing.
+// Transport errors, 429 and 5xx responses count as failures.
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+
+	mu         sync.Mutex
+	state      CircuitState
+	generation uint64
+	failures   int
+	successes  int
+	probes     int
+	openedAt   time.Time
+}
+
+// circuitTicket records how a request was admitted by the circuit breaker
+type circuitTicket struct {
+	// probe is set for requests admitted while the circuit was half-open
+	probe bool
+	// generation is the state change count when the request was admitted
+	generation uint64
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenMaxRequests < 1 {
+		settings.HalfOpenMaxRequests = 1
+	}
+	return &CircuitBreaker{settings: settings}
+}
+
+// State returns the current state of the circuit
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= cb.settings.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// Middleware returns the circuit breaker as a hub Middleware
+func (cb *CircuitBreaker) Middleware() Middleware {
+	return func(next utils.HTTPClient) utils.HTTPClient {
+		return utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+			ticket, ok := cb.allow()
+			if !ok {
+				return nil, nil, NewError(ErrorCodeCircuitOpen, ErrCircuitOpen.Message)
+			}
+			raw, resp, err := next.Exec(req)
+			cb.record(req.Context(), ticket, resp, err)
+			return raw, resp, err
+		})
+	}
+}
+
+// allow identifies whether a request may be sent and returns the ticket to record its outcome with
+func (cb *CircuitBreaker) allow() (circuitTicket, bool) {
+	cb.mu.Lock()
+	var transition func()
+	defer func() {
+		cb.mu.Unlock()
+		if transition != nil {
+			transition()
+		}
+	}()
+
+	switch cb.state {
+	case CircuitClosed:
+		return circuitTicket{generation: cb.generation}, true
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.settings.OpenTimeout {
+			return circuitTicket{}, false
+		}
+		transition = cb.setState(CircuitHalfOpen)
+	}
+
+	if cb.probes >= cb.settings.HalfOpenMaxRequests {
+		return circuitTicket{}, false
+	}
+	cb.probes++
+	return circuitTicket{probe: true, generation: cb.generation}, true
+}
+
+// record updates the circuit with the outcome of a request admitted with ticket.
+// Requests admitted before the last state change say nothing about the current state and are ignored.
+func (cb *CircuitBreaker) record(ctx context.Context, ticket circuitTicket, resp *http.Response, err error) {
+	cb.mu.Lock()
+	var transition func()
+	defer func() {
+		cb.mu.Unlock()
+		if transition != nil {
+			transition()
+		}
+	}()
+
+	if ticket.generation != cb.generation {
+		return
+	}
+	if ticket.probe {
+		cb.probes--
+	}
+
+	if isCircuitFailure(ctx, resp, err) {
+		cb.successes = 0
+		cb.failures++
+		if cb.state == CircuitHalfOpen || cb.failures >= cb.settings.FailureThreshold {
+			transition = cb.setState(CircuitOpen)
+		}
+		return
+	}
+	if err != nil && resp == nil {
+		// the caller gave up, this says nothing about the hub
+		return
+	}
+
+	cb.failures = 0
+	if cb.state == CircuitHalfOpen {
+		cb.successes++
+		if cb.successes >= cb.settings.HalfOpenMaxRequests {
+			transition = cb.setState(CircuitClosed)
+		}
+	}
+}
+
+// setState moves the circuit to state and returns the state change callback to run
+func (cb *CircuitBreaker) setState(state CircuitState) func() {
+	from := cb.state
+	if from == state {
+		return nil
+	}
+	cb.state = state
+	cb.generation++
+	cb.failures = 0
+	cb.successes = 0
+	cb.probes = 0
+	if state == CircuitOpen {
+		cb.openedAt = time.Now()
+	}
+	if cb.settings.OnStateChange == nil {
+		return nil
+	}
+	return func() { cb.settings.OnStateChange(from, state) }
+}
+
+// isCircuitFailure identifies whether a request outcome indicates a failing hub
+func isCircuitFailure(ctx context.Context, resp *http.Response, err error) bool {
+	if err == nil {
+		return false
+	}
+	if resp == nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
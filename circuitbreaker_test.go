@@ -0,0 +1,167 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func Test_CircuitBreaker(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		ctx                            = context.Background()
+		status                         = http.StatusServiceUnavailable
+		calls                          int
+		mu                             sync.Mutex
+		transitions                    []string
+		breaker                        = NewCircuitBreaker(CircuitBreakerSettings{
+			FailureThreshold: 2,
+			OpenTimeout:      20 * time.Millisecond,
+			OnStateChange: func(from, to CircuitState) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, from.String()+"->"+to.String())
+			},
+		})
+	)
+	nhub = nhub.With(WithMiddleware(breaker.Middleware()))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		calls++
+		if status != http.StatusCreated {
+			return statusErrorResponse(status, nil, "")
+		}
+		return nil, &http.Response{StatusCode: status, Header: http.Header{}}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := nhub.Send(ctx, notification, nil); err == nil {
+			t.Fatalf(errfmt, "error", "service unavailable", nil)
+		}
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf(errfmt, "state", CircuitOpen, breaker.State())
+	}
+
+	_, _, err := nhub.Send(ctx, notification, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf(errfmt, "error", ErrCircuitOpen, err)
+	}
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Operation != "Send" {
+		t.Errorf(errfmt, "typed error", "Send", err)
+	}
+	if calls != 2 {
+		t.Errorf(errfmt, "calls", 2, calls)
+	}
+
+	// a failing probe opens the circuit again
+	time.Sleep(25 * time.Millisecond)
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf(errfmt, "state", CircuitHalfOpen, breaker.State())
+	}
+	if _, _, err = nhub.Send(ctx, notification, nil); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf(errfmt, "probe", "sent", err)
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf(errfmt, "state", CircuitOpen, breaker.State())
+	}
+
+	// a successful probe closes it
+	status = http.StatusCreated
+	time.Sleep(25 * time.Millisecond)
+	if _, _, err = nhub.Send(ctx, notification, nil); err != nil {
+		t.Errorf(errfmt, "error", nil, err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf(errfmt, "state", CircuitClosed, breaker.State())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := "closed->open,open->half-open,half-open->open,open->half-open,half-open->closed"
+	if got := strings.Join(transitions, ","); got != expected {
+		t.Errorf(errfmt, "transitions", expected, got)
+	}
+}
+
+func Test_CircuitBreakerIgnoresClientErrors(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		breaker                        = NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1})
+	)
+	nhub = nhub.With(WithMiddleware(breaker.Middleware()))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return statusErrorResponse(http.StatusBadRequest, nil, "")
+	}
+
+	for i := 0; i < 3; i++ {
+		_, _, _ = nhub.Send(context.Background(), notification, nil)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf(errfmt, "state", CircuitClosed, breaker.State())
+	}
+}
+
+func Test_CircuitBreakerCountsOnlyProbesWhenHalfOpen(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		ctx                            = context.Background()
+		breaker                        = NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond})
+		started                        = make(chan struct{})
+		release                        = make(chan struct{})
+		done                           = make(chan struct{})
+	)
+	nhub = nhub.With(WithMiddleware(breaker.Middleware()))
+
+	// the first request is admitted while the circuit is closed and finishes once it is half-open
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		close(started)
+		<-release
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+	go func() {
+		defer close(done)
+		_, _, _ = nhub.Send(ctx, notification, nil)
+	}()
+	<-started
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return statusErrorResponse(http.StatusServiceUnavailable, nil, "")
+	}
+	_, _, _ = nhub.Send(ctx, notification, nil)
+	time.Sleep(25 * time.Millisecond)
+
+	// a slow probe keeps the circuit half-open while the first request finishes
+	probeStarted, probeRelease := make(chan struct{}), make(chan struct{})
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		close(probeStarted)
+		<-probeRelease
+		return statusErrorResponse(http.StatusServiceUnavailable, nil, "")
+	}
+	probeDone := make(chan struct{})
+	go func() {
+		defer close(probeDone)
+		_, _, _ = nhub.Send(ctx, notification, nil)
+	}()
+	<-probeStarted
+
+	close(release)
+	<-done
+	if breaker.State() != CircuitHalfOpen {
+		t.Errorf(errfmt, "state after the stale request", CircuitHalfOpen, breaker.State())
+	}
+
+	close(probeRelease)
+	<-probeDone
+	if breaker.State() != CircuitOpen {
+		t.Errorf(errfmt, "state after the failed probe", CircuitOpen, breaker.State())
+	}
+}
@@ -0,0 +1,335 @@
+package notificationhubs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+// Environment variables read by LoadConfig
+const (
+	EnvConnectionString = "NOTIFICATIONHUB_CONNECTION_STRING"
+	EnvHubPath          = "NOTIFICATIONHUB_PATH"
+	EnvAPIVersion       = "NOTIFICATIONHUB_API_VERSION"
+	EnvTimeout          = "NOTIFICATIONHUB_TIMEOUT"
+	EnvRetryMaxAttempts = "NOTIFICATIONHUB_RETRY_MAX_ATTEMPTS"
+	EnvRetryBaseDelay   = "NOTIFICATIONHUB_RETRY_BASE_DELAY"
+	EnvRetryMaxDelay    = "NOTIFICATIONHUB_RETRY_MAX_DELAY"
+	EnvTokenLifetime    = "NOTIFICATIONHUB_TOKEN_LIFETIME"
+	EnvUserAgent        = "NOTIFICATIONHUB_USER_AGENT"
+	EnvConfigFile       = "NOTIFICATIONHUB_CONFIG_FILE"
+)
+
+type (
+	// Config holds the hub settings loaded by LoadConfig.
+	// Zero values keep the defaults of the hub.
+	Config struct {
+		// ConnectionString is the hub connection string, it is a secret
+		ConnectionString string
+		// HubPath is the hub name, it may be empty when the connection string has an EntityPath
+		HubPath string
+		// APIVersion is the REST API version, see WithAPIVersion
+		APIVersion string
+		// Timeout limits a single request attempt
+		Timeout time.Duration
+		// RetryMaxAttempts enables retries of throttled and unavailable responses, see utils.RetryPolicy
+		RetryMaxAttempts int
+		// RetryBaseDelay overrides the base delay of the default retry policy
+		RetryBaseDelay time.Duration
+		// RetryMaxDelay overrides the maximum delay of the default retry policy
+		RetryMaxDelay time.Duration
+		// TokenLifetime is the lifetime of signed SAS tokens, see WithTokenLifetime
+		TokenLifetime time.Duration
+		// UserAgent is the User-Agent header of hub requests
+		UserAgent string
+	}
+
+	// configSetting is a Config field read from a JSON key or an environment variable
+	configSetting struct {
+		key    string
+		env    string
+		secret bool
+		set    func(c *Config, value string) error
+	}
+)
+
+// configSettings are all settings read by LoadConfig
+var configSettings = []configSetting{
+	{key: "connectionString", env: EnvConnectionString, secret: true, set: func(c *Config, v string) error {
+		c.ConnectionString = v
+		return nil
+	}},
+	{key: "hubPath", env: EnvHubPath, set: func(c *Config, v string) error {
+		c.HubPath = v
+		return nil
+	}},
+	{key: "apiVersion", env: EnvAPIVersion, set: func(c *Config, v string) error {
+		c.APIVersion = v
+		return nil
+	}},
+	{key: "timeout", env: EnvTimeout, set: durationSetting(func(c *Config) *time.Duration { return &c.Timeout })},
+	{key: "retryMaxAttempts", env: EnvRetryMaxAttempts, set: func(c *Config, v string) (err error) {
+		c.RetryMaxAttempts, err = strconv.Atoi(v)
+		return err
+	}},
+	{key: "retryBaseDelay", env: EnvRetryBaseDelay, set: durationSetting(func(c *Config) *time.Duration { return &c.RetryBaseDelay })},
+	{key: "retryMaxDelay", env: EnvRetryMaxDelay, set: durationSetting(func(c *Config) *time.Duration { return &c.RetryMaxDelay })},
+	{key: "tokenLifetime", env: EnvTokenLifetime, set: durationSetting(func(c *Config) *time.Duration { return &c.TokenLifetime })},
+	{key: "userAgent", env: EnvUserAgent, set: func(c *Config, v string) error {
+		c.UserAgent = v
+		return nil
+	}},
+}
+
+// LoadConfig loads hub settings from the JSON file at path and from the environment.
+// When path is empty the file named by NOTIFICATIONHUB_CONFIG_FILE is read, if any.
+//
+// Environment variables take precedence over the file, which takes precedence over the defaults.
+// The file holds an object with the keys connectionString, hubPath, apiVersion, timeout,
+// retryMaxAttempts, retryBaseDelay, retryMaxDelay, tokenLifetime and userAgent.
+// Durations are written as Go durations such as "30s", in the file and in the environment.
+//
+// Invalid values are reported as a *ValidationError naming the key or environment variable,
+// an invalid connection string as a *NotificationHubError.
+func LoadConfig(path string) (*Config, error) {
+	c := &Config{}
+
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := c.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range configSettings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.apply(c, s.env, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the connection string, the hub path and the numeric settings
+func (c *Config) Validate() error {
+	cs, err := ParseConnectionString(c.ConnectionString)
+	if err != nil {
+		return err
+	}
+	if c.HubPath == "" && cs.EntityPath == "" {
+		return NewValidationError("hubPath", "is required when the connection string has no EntityPath", c.HubPath)
+	}
+	if c.APIVersion != "" && !ValidAPIVersion(c.APIVersion) {
+		return NewValidationError("apiVersion", "is not a valid api-version such as "+DefaultAPIVersion, c.APIVersion)
+	}
+	if c.RetryMaxAttempts < 0 {
+		return NewValidationError("retryMaxAttempts", "must not be negative", c.RetryMaxAttempts)
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"timeout", c.Timeout},
+		{"retryBaseDelay", c.RetryBaseDelay},
+		{"retryMaxDelay", c.RetryMaxDelay},
+		{"tokenLifetime", c.TokenLifetime},
+	} {
+		if d.value < 0 {
+			return NewValidationError(d.name, "must not be negative", d.value)
+		}
+	}
+	return nil
+}
+
+// Options returns the hub options applying the settings
+func (c *Config) Options() []HubOption {
+	var opts []HubOption
+	if c.APIVersion != "" {
+		opts = append(opts, WithAPIVersion(c.APIVersion))
+	}
+	if c.TokenLifetime > 0 {
+		opts = append(opts, WithTokenLifetime(c.TokenLifetime))
+	}
+	if c.UserAgent != "" {
+		opts = append(opts, WithUserAgent(c.UserAgent))
+	}
+
+	if c.Timeout > 0 {
+		opts = append(opts, WithHTTPClient(utils.NewHubHTTPClient(utils.WithTimeout(c.Timeout))))
+	}
+	if c.RetryMaxAttempts > 0 {
+		policy := utils.DefaultRetryPolicy()
+		policy.MaxAttempts = c.RetryMaxAttempts
+		if c.RetryBaseDelay > 0 {
+			policy.BaseDelay = c.RetryBaseDelay
+		}
+		if c.RetryMaxDelay > 0 {
+			policy.MaxDelay = c.RetryMaxDelay
+		}
+		opts = append(opts, WithRetryPolicy(policy))
+	}
+	return opts
+}
+
+// NewNotificationHub creates a hub with the settings.
+// opts are applied after the settings, so they take precedence.
+func (c *Config) NewNotificationHub(opts ...HubOption) (*NotificationHub, error) {
+	return newNotificationHubE(c.ConnectionString, c.HubPath, append(c.Options(), opts...)...)
+}
+
+// String returns the settings with the connection string key redacted
+func (c Config) String() string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, attr := range c.attrs() {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%s:%v", attr.Key, attr.Value)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// GoString redacts the connection string key in %#v output
+func (c Config) GoString() string {
+	return "notificationhubs.Config" + c.String()
+}
+
+// LogValue implements slog.LogValuer so a logged config never reveals its key
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(c.attrs()...)
+}
+
+// MarshalJSON implements json.Marshaler, redacting the connection string key
+func (c Config) MarshalJSON() ([]byte, error) {
+	type plainConfig Config
+	redactedConfig := plainConfig(c)
+	redactedConfig.ConnectionString = redactConnectionString(c.ConnectionString)
+	return json.Marshal(redactedConfig)
+}
+
+// attrs returns the settings with secrets redacted
+func (c Config) attrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String("ConnectionString", redactConnectionString(c.ConnectionString)),
+		slog.String("HubPath", c.HubPath),
+		slog.String("APIVersion", c.APIVersion),
+		slog.Duration("Timeout", c.Timeout),
+		slog.Int("RetryMaxAttempts", c.RetryMaxAttempts),
+		slog.Duration("RetryBaseDelay", c.RetryBaseDelay),
+		slog.Duration("RetryMaxDelay", c.RetryMaxDelay),
+		slog.Duration("TokenLifetime", c.TokenLifetime),
+		slog.String("UserAgent", c.UserAgent),
+	}
+}
+
+// readFile applies the settings of the JSON file at path
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return NewErrorWithCause(ErrorCodeInvalidConfig, "cannot read config file "+path, err)
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return NewErrorWithCause(ErrorCodeInvalidConfig, "malformed config file "+path, err)
+	}
+
+	for key := range values {
+		if findConfigSetting(key) == nil {
+			return NewValidationError(key, "is not a known setting of config file "+path, key)
+		}
+	}
+	for _, s := range configSettings {
+		raw, ok := values[s.key]
+		if !ok {
+			continue
+		}
+		value, err := rawSettingValue(raw)
+		if err != nil {
+			return NewValidationError(s.key, "must be a string or a number", s.redact(string(raw)))
+		}
+		if err := s.apply(c, s.key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply sets the setting to value read from source
+func (s *configSetting) apply(c *Config, source, value string) error {
+	if err := s.set(c, strings.TrimSpace(value)); err != nil {
+		return NewValidationError(source, err.Error(), s.redact(value))
+	}
+	return nil
+}
+
+// redactConnectionString returns the connection string with its key redacted.
+// It is rebuilt from the parsed fields, so spacing or casing cannot leak the key.
+// A connection string that does not parse is redacted entirely.
+func redactConnectionString(connectionString string) string {
+	if connectionString == "" {
+		return ""
+	}
+	cs, err := ParseConnectionString(connectionString)
+	if err != nil {
+		return redacted
+	}
+	return cs.String()
+}
+
+// redact hides the value of a secret setting in errors
+func (s *configSetting) redact(value string) string {
+	if s.secret {
+		return redacted
+	}
+	return value
+}
+
+// findConfigSetting returns the setting read from the JSON key, or nil
+func findConfigSetting(key string) *configSetting {
+	for i := range configSettings {
+		if configSettings[i].key == key {
+			return &configSettings[i]
+		}
+	}
+	return nil
+}
+
+// rawSettingValue returns a JSON string or number as a string
+func rawSettingValue(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var n json.Number
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
+
+// durationSetting returns a setter parsing a Go duration into the field returned by field
+func durationSetting(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) (err error) {
+		*field(c), err = time.ParseDuration(value)
+		return err
+	}
+}
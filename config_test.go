@@ -0,0 +1,191 @@
+package notificationhubs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "notificationhub.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_LoadConfigFromEnvironment(t *testing.T) {
+	t.Setenv(EnvConnectionString, connectionString)
+	t.Setenv(EnvHubPath, hubPath)
+	t.Setenv(EnvTimeout, "15s")
+	t.Setenv(EnvRetryMaxAttempts, "3")
+	t.Setenv(EnvUserAgent, "my-sender/1.0")
+
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	expected := Config{
+		ConnectionString: connectionString,
+		HubPath:          hubPath,
+		Timeout:          15 * time.Second,
+		RetryMaxAttempts: 3,
+		UserAgent:        "my-sender/1.0",
+	}
+	if *config != expected {
+		t.Errorf(errfmt, "config", expected, config)
+	}
+}
+
+func Test_LoadConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"connectionString": "`+connectionString+`",
+		"hubPath": "file-hub",
+		"apiVersion": "2015-01",
+		"timeout": "5s",
+		"retryMaxAttempts": 2,
+		"tokenLifetime": "10m"
+	}`)
+	t.Setenv(EnvConfigFile, path)
+	t.Setenv(EnvHubPath, hubPath)
+	t.Setenv(EnvTimeout, "20s")
+
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if config.HubPath != hubPath || config.Timeout != 20*time.Second {
+		t.Errorf(errfmt, "environment overrides", hubPath+" 20s", config)
+	}
+	if config.APIVersion != "2015-01" || config.RetryMaxAttempts != 2 || config.TokenLifetime != 10*time.Minute {
+		t.Errorf(errfmt, "file settings", "2015-01 2 10m", config)
+	}
+}
+
+func Test_LoadConfigErrors(t *testing.T) {
+	validFile := writeConfigFile(t, `{"connectionString": "`+connectionString+`", "hubPath": "`+hubPath+`"}`)
+
+	tests := []struct {
+		name  string
+		path  string
+		env   map[string]string
+		field string
+		code  ErrorCode
+	}{
+		{name: "missing connection string", env: map[string]string{EnvHubPath: hubPath}, code: ErrorCodeInvalidConnectionString},
+		{name: "missing hub path", env: map[string]string{EnvConnectionString: connectionString}, field: "hubPath"},
+		{name: "malformed duration", path: validFile, env: map[string]string{EnvTimeout: "30"}, field: EnvTimeout},
+		{name: "malformed number", path: validFile, env: map[string]string{EnvRetryMaxAttempts: "many"}, field: EnvRetryMaxAttempts},
+		{name: "negative duration", path: validFile, env: map[string]string{EnvRetryMaxDelay: "-1s"}, field: "retryMaxDelay"},
+		{name: "unknown key", path: writeConfigFile(t, `{"hubpath": "x"}`), field: "hubpath"},
+		{name: "wrong type", path: writeConfigFile(t, `{"timeout": ["5s"]}`), field: "timeout"},
+		{name: "malformed file", path: writeConfigFile(t, `{`), code: ErrorCodeInvalidConfig},
+		{name: "missing file", path: filepath.Join(t.TempDir(), "missing.json"), code: ErrorCodeInvalidConfig},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := LoadConfig(test.path)
+
+			var (
+				validationErr *ValidationError
+				hubErr        *NotificationHubError
+			)
+			switch {
+			case test.field != "":
+				if !errors.As(err, &validationErr) || validationErr.Field != test.field {
+					t.Errorf(errfmt, "validation error", test.field, err)
+				}
+			case !errors.As(err, &hubErr) || hubErr.Code != test.code:
+				t.Errorf(errfmt, "error code", test.code, err)
+			}
+		})
+	}
+}
+
+func Test_ConfigRedactsSecrets(t *testing.T) {
+	var (
+		config = Config{ConnectionString: connectionString, HubPath: hubPath}
+		logs   = &bytes.Buffer{}
+	)
+	slog.New(slog.NewTextHandler(logs, nil)).Info("loaded", "config", config)
+	marshaled, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf(errfmt, "marshal error", nil, err)
+	}
+
+	for _, printed := range []string{config.String(), fmt.Sprintf("%v", config), fmt.Sprintf("%+v", &config), fmt.Sprintf("%#v", config), logs.String(), string(marshaled)} {
+		if strings.Contains(printed, "SharedAccessKey=testAccessKey") {
+			t.Errorf(errfmt, "printed config", "redacted key", printed)
+		}
+		if !strings.Contains(printed, "testAccessKeyName") || !strings.Contains(printed, hubPath) {
+			t.Errorf(errfmt, "printed config", "key name and hub path", printed)
+		}
+	}
+
+	spaced := Config{ConnectionString: "Endpoint=sb://x/;SharedAccessKeyName=n; SharedAccessKey =secret-key"}
+	if printed := spaced.String(); strings.Contains(printed, "secret-key") {
+		t.Errorf(errfmt, "printed config", "redacted key", printed)
+	}
+
+	t.Setenv(EnvConnectionString, "Endpoint=sb://x/;SharedAccessKeyName=n;SharedAccessKey=secret-key;Bogus=1")
+	t.Setenv(EnvHubPath, hubPath)
+	if _, err := LoadConfig(""); err == nil || strings.Contains(err.Error(), "secret-key") {
+		t.Errorf(errfmt, "connection string error", "redacted error", err)
+	}
+}
+
+func Test_ConfigNewNotificationHub(t *testing.T) {
+	var (
+		mockClient = &mockHubHTTPClient{}
+		config     = Config{
+			ConnectionString: connectionString,
+			HubPath:          hubPath,
+			APIVersion:       LegacyAPIVersion,
+			Timeout:          10 * time.Second,
+			UserAgent:        "from-config",
+		}
+	)
+
+	nhub, err := config.NewNotificationHub(WithHTTPClient(mockClient))
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	var called bool
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		called = true
+		if got := req.Header.Get("User-Agent"); got != "from-config" {
+			t.Errorf(errfmt, "User-Agent", "from-config", got)
+		}
+		if got := req.URL.Query().Get(apiVersionParam); got != LegacyAPIVersion {
+			t.Errorf(errfmt, "api-version", LegacyAPIVersion, got)
+		}
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+
+	notification, _ := NewNotification(Template, []byte("{}"))
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if !called {
+		t.Errorf(errfmt, "HTTP client", "option overriding the config client", "config client")
+	}
+}
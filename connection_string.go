@@ -0,0 +1,127 @@
+package notificationhubs
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ConnectionString is a parsed notification hub connection string
+type ConnectionString struct {
+	// Endpoint is the namespace URL, such as sb://mynamespace.servicebus.windows.net/
+	Endpoint string
+	// SharedAccessKeyName is the name of the shared access key
+	SharedAccessKeyName string
+	// SharedAccessKey is the shared access key
+	SharedAccessKey string
+	// EntityPath is the hub path, it is optional
+	EntityPath string
+}
+
+// ParseConnectionString parses a connection string as copied from the Azure portal.
+// Unknown, duplicate or malformed segments and missing keys are reported as
+// a *NotificationHubError with ErrorCodeInvalidConnectionString.
+func ParseConnectionString(connectionString string) (*ConnectionString, error) {
+	var (
+		cs   = &ConnectionString{}
+		seen = map[string]bool{}
+	)
+	for _, segment := range strings.Split(connectionString, ";") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(segment, "=")
+		if !ok || key == "" {
+			return nil, invalidConnectionString("malformed segment, expected Key=Value")
+		}
+
+		// Azure treats the keys case insensitively
+		name := strings.ToLower(strings.TrimSpace(key))
+		if seen[name] {
+			return nil, invalidConnectionString(fmt.Sprintf("duplicate %s", key))
+		}
+		seen[name] = true
+
+		switch name {
+		case "endpoint":
+			cs.Endpoint = value
+		case "sharedaccesskeyname":
+			cs.SharedAccessKeyName = value
+		case "sharedaccesskey":
+			cs.SharedAccessKey = value
+		case "entitypath":
+			cs.EntityPath = value
+		default:
+			return nil, invalidConnectionString(fmt.Sprintf("unknown segment %s", key))
+		}
+	}
+
+	if _, err := cs.endpointURL(); err != nil {
+		return nil, err
+	}
+	switch {
+	case cs.SharedAccessKeyName == "":
+		return nil, invalidConnectionString("missing SharedAccessKeyName")
+	case cs.SharedAccessKey == "":
+		return nil, invalidConnectionString("missing SharedAccessKey")
+	}
+	return cs, nil
+}
+
+// String returns the connection string with its key redacted
+func (cs *ConnectionString) String() string {
+	s := fmt.Sprintf("Endpoint=%s;SharedAccessKeyName=%s;SharedAccessKey=%s", cs.Endpoint, cs.SharedAccessKeyName, redacted)
+	if cs.EntityPath != "" {
+		s += ";EntityPath=" + cs.EntityPath
+	}
+	return s
+}
+
+// endpointURL returns the validated endpoint as an https URL
+func (cs *ConnectionString) endpointURL() (*url.URL, error) {
+	if cs.Endpoint == "" {
+		return nil, invalidConnectionString("missing Endpoint")
+	}
+	endpoint, err := url.Parse(cs.Endpoint)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrorCodeInvalidConnectionString, "invalid connection string: malformed Endpoint", err)
+	}
+	switch endpoint.Scheme {
+	case schemeServiceBus, schemeDefault, "http":
+	default:
+		return nil, invalidConnectionString(fmt.Sprintf("unsupported Endpoint scheme %q", endpoint.Scheme))
+	}
+	if endpoint.Host == "" {
+		return nil, invalidConnectionString("Endpoint has no host")
+	}
+	if endpoint.Scheme == schemeServiceBus {
+		endpoint.Scheme = schemeDefault
+	}
+	return endpoint, nil
+}
+
+// invalidConnectionString returns an ErrorCodeInvalidConnectionString error
+func invalidConnectionString(reason string) error {
+	return NewError(ErrorCodeInvalidConnectionString, "invalid connection string: "+reason)
+}
+
+// newNotificationHubE validates the connection string and hub path
+// before creating the hub
+func newNotificationHubE(connectionString, hubPath string, opts ...HubOption) (*NotificationHub, error) {
+	cs, err := ParseConnectionString(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case hubPath == "" && cs.EntityPath == "":
+		return nil, NewValidationError("hubPath", "is required when the connection string has no EntityPath", hubPath)
+	case hubPath == "":
+		hubPath = cs.EntityPath
+	case cs.EntityPath != "" && cs.EntityPath != hubPath:
+		return nil, NewValidationError("hubPath", "does not match the connection string EntityPath "+cs.EntityPath, hubPath)
+	}
+
+	endpoint, _ := cs.endpointURL()
+	return newHub(endpoint, cs.SharedAccessKeyName, cs.SharedAccessKey, hubPath, opts...), nil
+}
@@ -0,0 +1,80 @@
+package notificationhubs_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func Test_ParseConnectionString(t *testing.T) {
+	cs, err := ParseConnectionString("Endpoint=sb://testhub-ns.servicebus.windows.net/;SharedAccessKeyName=testAccessKeyName;SharedAccessKey=key/with+base64==;EntityPath=testhub;")
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	expected := ConnectionString{
+		Endpoint:            "sb://testhub-ns.servicebus.windows.net/",
+		SharedAccessKeyName: "testAccessKeyName",
+		SharedAccessKey:     "key/with+base64==",
+		EntityPath:          "testhub",
+	}
+	if *cs != expected {
+		t.Errorf(errfmt, "connection string", expected, *cs)
+	}
+	if strings.Contains(cs.String(), "base64") {
+		t.Errorf(errfmt, "String", "redacted key", cs.String())
+	}
+}
+
+func Test_ParseConnectionStringErrors(t *testing.T) {
+	testCases := map[string]string{
+		"wrong_connection_string": "malformed segment",
+		"Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=name":                                   "missing SharedAccessKey",
+		"Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKey=key":                                        "missing SharedAccessKeyName",
+		"SharedAccessKeyName=name;SharedAccessKey=key":                                                        "missing Endpoint",
+		"Endpoint=ftp://ns.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key":              "unsupported Endpoint scheme",
+		"Endpoint=sb://;SharedAccessKeyName=name;SharedAccessKey=key":                                         "no host",
+		"Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key;Foo=bar":       "unknown segment Foo",
+		"Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key;endpoint=x":    "duplicate endpoint",
+		"Endpoint=sb://ns.servicebus.windows.net/%zz;SharedAccessKeyName=name;SharedAccessKey=secretKeyValue": "malformed Endpoint",
+	}
+
+	for connectionString, reason := range testCases {
+		_, err := ParseConnectionString(connectionString)
+		var hubErr *NotificationHubError
+		if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeInvalidConnectionString {
+			t.Errorf(errfmt, connectionString, ErrorCodeInvalidConnectionString, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), reason) {
+			t.Errorf(errfmt, "reason", reason, err)
+		}
+		if strings.Contains(err.Error(), "secretKeyValue") {
+			t.Errorf(errfmt, "error message", "no key", err)
+		}
+	}
+}
+
+func Test_NewNotificationHubE(t *testing.T) {
+	nhub, err := NewNotificationHubE(connectionString+";EntityPath=testhub", "")
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if nhub.HubURL.String() != "https://testhub-ns.servicebus.windows.net/testhub?api-version=2016-07" {
+		t.Errorf(errfmt, "hub URL", "hub from EntityPath", nhub.HubURL)
+	}
+	if nhub.SasKeyName != "testAccessKeyName" || nhub.SasKeyValue != "testAccessKey" {
+		t.Errorf(errfmt, "keys", "from connection string", nhub.SasKeyName)
+	}
+
+	if _, err = NewNotificationHubE(connectionString, ""); err == nil {
+		t.Errorf(errfmt, "missing hub path", "error", err)
+	}
+	if _, err = NewNotificationHubE(connectionString+";EntityPath=otherhub", hubPath); err == nil {
+		t.Errorf(errfmt, "conflicting hub path", "error", err)
+	}
+	if _, err = NewNotificationHubE("wrong_connection_string", hubPath); err == nil {
+		t.Errorf(errfmt, "invalid connection string", "error", err)
+	}
+}
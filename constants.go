@@ -28,6 +28,7 @@ const (
 	WNSPlatform   InstallationPlatform = "wns"
 	MPNSPlatform  InstallationPlatform = "mpns"
 	ADMPlatform   InstallationPlatform = "adm"
+	BAIDUPlatform InstallationPlatform = "baidu"
 	FCMV1Platform InstallationPlatform = "fcmv1"
 
 	InstallationChangeAdd     InstallationChangeOp = "add"
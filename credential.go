@@ -0,0 +1,191 @@
+package notificationhubs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTokenScope is the scope requested for hub bearer tokens
+	DefaultTokenScope = "https://servicebus.azure.net/.default"
+
+	// defaultTokenEndpoint is the Microsoft Entra ID token endpoint of a tenant
+	defaultTokenEndpoint = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+)
+
+type (
+	// AccessToken is a bearer token and the time it expires
+	AccessToken struct {
+		Token     string
+		ExpiresOn time.Time
+	}
+
+	// TokenCredential provides bearer tokens authorizing hub requests,
+	// as an alternative to shared access keys
+	TokenCredential interface {
+		GetToken(ctx context.Context) (AccessToken, error)
+	}
+
+	// TokenCredentialFunc is a function providing bearer tokens like a TokenCredential
+	TokenCredentialFunc func(ctx context.Context) (AccessToken, error)
+
+	// ClientSecretCredential obtains bearer tokens from Microsoft Entra ID
+	// with the OAuth 2.0 client credentials grant
+	ClientSecretCredential struct {
+		endpoint     string
+		clientID     string
+		clientSecret string
+		scope        string
+		httpClient   *http.Client
+	}
+
+	// ClientSecretCredentialOption configures a ClientSecretCredential
+	ClientSecretCredentialOption func(*ClientSecretCredential)
+
+	// bearerTokenCache keeps the last bearer token of credential until it is close to expiry
+	bearerTokenCache struct {
+		mu         sync.Mutex
+		token      AccessToken
+		credential TokenCredential
+	}
+
+	// tokenResponse is the body of a token endpoint response
+	tokenResponse struct {
+		AccessToken      string          `json:"access_token"`
+		ExpiresIn        json.RawMessage `json:"expires_in"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+)
+
+// GetToken calls f(ctx)
+func (f TokenCredentialFunc) GetToken(ctx context.Context) (AccessToken, error) {
+	return f(ctx)
+}
+
+// NewClientSecretCredential creates a credential for the app registration clientID of tenantID
+func NewClientSecretCredential(tenantID, clientID, clientSecret string, opts ...ClientSecretCredentialOption) *ClientSecretCredential {
+	c := &ClientSecretCredential{
+		endpoint:     fmt.Sprintf(defaultTokenEndpoint, url.PathEscape(tenantID)),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scope:        DefaultTokenScope,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithTokenEndpoint makes the credential request tokens from endpoint instead of Microsoft Entra ID
+func WithTokenEndpoint(endpoint string) ClientSecretCredentialOption {
+	return func(c *ClientSecretCredential) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithTokenScope makes the credential request tokens for scope, DefaultTokenScope by default
+func WithTokenScope(scope string) ClientSecretCredentialOption {
+	return func(c *ClientSecretCredential) {
+		c.scope = scope
+	}
+}
+
+// WithTokenHTTPClient makes the credential send token requests through client
+func WithTokenHTTPClient(client *http.Client) ClientSecretCredentialOption {
+	return func(c *ClientSecretCredential) {
+		c.httpClient = client
+	}
+}
+
+// GetToken requests a new bearer token from the token endpoint
+func (c *ClientSecretCredential) GetToken(ctx context.Context) (AccessToken, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+		"scope":         {c.scope},
+	}
+	req, err := http.NewRequestWithContext(ctx, postMethod, c.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	requested := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return AccessToken{}, fmt.Errorf("could not decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return AccessToken{}, fmt.Errorf("token request failed with status %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+
+	// expires_in is a number, or a string in older endpoint versions
+	expiresIn, err := strconv.ParseInt(strings.Trim(string(body.ExpiresIn), `"`), 10, 64)
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("invalid expires_in in token response: %s", body.ExpiresIn)
+	}
+	return AccessToken{
+		Token:     body.AccessToken,
+		ExpiresOn: requested.Add(time.Duration(expiresIn) * time.Second),
+	}, nil
+}
+
+// authorization returns the Authorization header of a hub request
+func (h *NotificationHub) authorization(ctx context.Context, cfg *hubConfig) (requestAuthorization, error) {
+	if cfg.bearerToken != nil {
+		token, err := cfg.bearerToken.get(ctx, cfg.tokenRenewalSkew)
+		if err != nil {
+			return requestAuthorization{}, err
+		}
+		return requestAuthorization{header: "Bearer " + token}, nil
+	}
+
+	key, slot, err := h.sasKey(ctx)
+	if err != nil {
+		return requestAuthorization{}, err
+	}
+	return requestAuthorization{header: h.generateSasToken(cfg, key), shared: true, slot: slot}, nil
+}
+
+// get returns the cached bearer token, requesting a new one when it expires within skew.
+// A failed renewal keeps using the current token until it actually expires.
+func (c *bearerTokenCache) get(ctx context.Context, skew time.Duration) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token.Token != "" && now.Add(skew).Before(c.token.ExpiresOn) {
+		return c.token.Token, nil
+	}
+
+	fresh, err := c.credential.GetToken(ctx)
+	if err == nil && fresh.Token == "" {
+		err = errors.New("credential returned an empty token")
+	}
+	if err != nil {
+		if c.token.Token != "" && now.Before(c.token.ExpiresOn) {
+			return c.token.Token, nil
+		}
+		return "", err
+	}
+	c.token = fresh
+	return c.token.Token, nil
+}
@@ -0,0 +1,143 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func newTokenServer(t *testing.T, body string, status int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{
+			"grant_type":    "client_credentials",
+			"client_id":     "client",
+			"client_secret": "secret",
+			"scope":         DefaultTokenScope,
+		}
+		for key, want := range expected {
+			if got := r.PostForm.Get(key); got != want {
+				t.Errorf(errfmt, key, want, got)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	return server, &calls
+}
+
+func Test_ClientSecretCredentialAuthorizesRequests(t *testing.T) {
+	var (
+		server, tokenCalls = newTokenServer(t, `{"token_type":"Bearer","access_token":"entra-token","expires_in":3600}`, http.StatusOK)
+		nhub, mockClient   = initTestItems()
+		authorizations     []string
+	)
+	defer server.Close()
+	nhub = nhub.With(WithTokenCredential(NewClientSecretCredential("tenant", "client", "secret", WithTokenEndpoint(server.URL))))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		authorizations = append(authorizations, req.Header.Get("Authorization"))
+		return nil, nil, nil
+	}
+
+	_ = nhub.Uninstall(context.Background(), "id")
+	_ = nhub.Uninstall(context.Background(), "id")
+
+	if *tokenCalls != 1 {
+		t.Errorf(errfmt, "token requests", 1, *tokenCalls)
+	}
+	for _, authorization := range authorizations {
+		if authorization != "Bearer entra-token" {
+			t.Errorf(errfmt, "Authorization", "Bearer entra-token", authorization)
+		}
+	}
+
+	nhub = nhub.With(WithTokenCredential(nil))
+	_ = nhub.Uninstall(context.Background(), "id")
+	if got := authorizations[len(authorizations)-1]; got[:22] != "SharedAccessSignature " {
+		t.Errorf(errfmt, "Authorization", "SharedAccessSignature", got)
+	}
+}
+
+func Test_ClientSecretCredentialStringExpiry(t *testing.T) {
+	server, _ := newTokenServer(t, `{"access_token":"entra-token","expires_in":"3599"}`, http.StatusOK)
+	defer server.Close()
+
+	token, err := NewClientSecretCredential("tenant", "client", "secret", WithTokenEndpoint(server.URL)).GetToken(context.Background())
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if remaining := time.Until(token.ExpiresOn); remaining < 3590*time.Second || remaining > 3599*time.Second {
+		t.Errorf(errfmt, "token expiry", "in 3599s", remaining)
+	}
+}
+
+func Test_ClientSecretCredentialFailure(t *testing.T) {
+	var (
+		server, _        = newTokenServer(t, `{"error":"invalid_client","error_description":"bad secret"}`, http.StatusUnauthorized)
+		nhub, mockClient = initTestItems()
+		hubCalls         int
+	)
+	defer server.Close()
+	nhub = nhub.With(WithTokenCredential(NewClientSecretCredential("tenant", "client", "secret", WithTokenEndpoint(server.URL))))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		hubCalls++
+		return nil, nil, nil
+	}
+
+	err := nhub.Uninstall(context.Background(), "id")
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeAuthenticationFailed {
+		t.Fatalf(errfmt, "error code", ErrorCodeAuthenticationFailed, err)
+	}
+	if hubCalls != 0 {
+		t.Errorf(errfmt, "hub requests", 0, hubCalls)
+	}
+}
+
+func Test_TokenCredentialProactiveRefresh(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		issued           int
+		failing          bool
+		authorization    string
+	)
+	nhub = nhub.With(WithTokenCredential(TokenCredentialFunc(func(ctx context.Context) (AccessToken, error) {
+		if failing {
+			return AccessToken{}, errors.New("token endpoint down")
+		}
+		issued++
+		// expires within the renewal skew, so every request renews it
+		return AccessToken{Token: "token" + string(rune('0'+issued)), ExpiresOn: time.Now().Add(time.Minute)}, nil
+	})))
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		authorization = req.Header.Get("Authorization")
+		return nil, nil, nil
+	}
+
+	_ = nhub.Uninstall(context.Background(), "id")
+	_ = nhub.Uninstall(context.Background(), "id")
+	if issued != 2 || authorization != "Bearer token2" {
+		t.Errorf(errfmt, "renewed token", "Bearer token2", authorization)
+	}
+
+	failing = true
+	if err := nhub.Uninstall(context.Background(), "id"); err != nil {
+		t.Errorf(errfmt, "error with a still valid token", nil, err)
+	}
+	if authorization != "Bearer token2" {
+		t.Errorf(errfmt, "kept token", "Bearer token2", authorization)
+	}
+}
@@ -1,8 +1,13 @@
 package notificationhubs
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
 )
 
 // ErrorCode represents specific error types that can occur
@@ -15,6 +20,8 @@ const (
 	ErrorCodeAuthenticationFailed ErrorCode = "AUTHENTICATION_FAILED"
 	// ErrorCodeUnauthorized indicates unauthorized access
 	ErrorCodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	// ErrorCodeInvalidConfig indicates a config file that cannot be read or parsed
+	ErrorCodeInvalidConfig ErrorCode = "INVALID_CONFIG"
 
 	// ErrorCodeInvalidRequest indicates an invalid request
 	ErrorCodeInvalidRequest ErrorCode = "INVALID_REQUEST"
@@ -24,6 +31,8 @@ const (
 	ErrorCodePayloadTooLarge ErrorCode = "PAYLOAD_TOO_LARGE"
 	// ErrorCodeInvalidTags indicates invalid tags
 	ErrorCodeInvalidTags ErrorCode = "INVALID_TAGS"
+	// ErrorCodeUnsupportedAPIVersion indicates the api-version is malformed or does not support the operation
+	ErrorCodeUnsupportedAPIVersion ErrorCode = "UNSUPPORTED_API_VERSION"
 
 	// ErrorCodeServerError indicates a server error
 	ErrorCodeServerError ErrorCode = "SERVER_ERROR"
@@ -36,6 +45,8 @@ const (
 	ErrorCodeRateLimited ErrorCode = "RATE_LIMITED"
 	// ErrorCodeQuotaExceeded indicates quota has been exceeded
 	ErrorCodeQuotaExceeded ErrorCode = "QUOTA_EXCEEDED"
+	// ErrorCodeCircuitOpen indicates the circuit breaker rejected the request
+	ErrorCodeCircuitOpen ErrorCode = "CIRCUIT_OPEN"
 
 	// ErrorCodeRegistrationNotFound indicates registration was not found
 	ErrorCodeRegistrationNotFound ErrorCode = "REGISTRATION_NOT_FOUND"
@@ -46,6 +57,18 @@ const (
 	ErrorCodeInstallationNotFound ErrorCode = "INSTALLATION_NOT_FOUND"
 	// ErrorCodeInvalidInstallation indicates invalid installation
 	ErrorCodeInvalidInstallation ErrorCode = "INVALID_INSTALLATION"
+
+	// ErrorCodeNotificationNotFound indicates the notification was not found
+	ErrorCodeNotificationNotFound ErrorCode = "NOTIFICATION_NOT_FOUND"
+	// ErrorCodeNotFound indicates the hub or another resource was not found
+	ErrorCodeNotFound ErrorCode = "NOT_FOUND"
+
+	// ErrorCodeRequestFailed indicates the request could not be sent or its response not be read
+	ErrorCodeRequestFailed ErrorCode = "REQUEST_FAILED"
+	// ErrorCodeInvalidResponse indicates the response could not be decoded
+	ErrorCodeInvalidResponse ErrorCode = "INVALID_RESPONSE"
+	// ErrorCodeResponseTooLarge indicates the response body exceeded the client limit
+	ErrorCodeResponseTooLarge ErrorCode = "RESPONSE_TOO_LARGE"
 )
 
 // NotificationHubError represents an error from the notification hub service
@@ -53,8 +76,11 @@ type NotificationHubError struct {
 	Code       ErrorCode
 	Message    string
 	Details    string
+	Operation  string
 	StatusCode int
 	RequestID  string
+	TrackingID string
+	RetryAfter time.Duration
 	Cause      error
 }
 
@@ -123,6 +149,10 @@ func NewErrorFromHTTPResponse(resp *http.Response, body []byte) *NotificationHub
 	err := &NotificationHubError{
 		StatusCode: resp.StatusCode,
 		RequestID:  resp.Header.Get("x-ms-request-id"),
+		TrackingID: resp.Header.Get("TrackingId"),
+	}
+	if retryAfter, ok := utils.ParseRetryAfter(resp.Header, time.Now()); ok {
+		err.RetryAfter = retryAfter
 	}
 
 	switch resp.StatusCode {
@@ -155,6 +185,9 @@ func NewErrorFromHTTPResponse(resp *http.Response, body []byte) *NotificationHub
 		err.Message = "Gateway timeout"
 	default:
 		err.Code = ErrorCodeServerError
+		if resp.StatusCode < http.StatusInternalServerError {
+			err.Code = ErrorCodeInvalidRequest
+		}
 		err.Message = fmt.Sprintf("HTTP %d", resp.StatusCode)
 	}
 
@@ -165,6 +198,51 @@ func NewErrorFromHTTPResponse(resp *http.Response, body []byte) *NotificationHub
 	return err
 }
 
+// newOperationError converts an error raised while running op into a *NotificationHubError.
+// A *NotificationHubError without an operation is returned as a copy with the operation filled in,
+// so shared errors such as ErrCircuitOpen are never modified. Errors wrapping one are returned as is,
+// any other error is reported with the given code.
+func newOperationError(op operation, code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var hubErr *NotificationHubError
+	if errors.As(err, &hubErr) {
+		if hubErr.Operation != "" || err != error(hubErr) {
+			return err
+		}
+		withOp := *hubErr
+		withOp.Operation = string(op)
+		return &withOp
+	}
+
+	var statusErr *utils.StatusError
+	if errors.As(err, &statusErr) && statusErr.Response != nil {
+		hubErr = NewErrorFromHTTPResponse(statusErr.Response, statusErr.Body)
+		if statusErr.Response.StatusCode == http.StatusNotFound {
+			hubErr.Code = op.notFoundCode()
+		}
+		hubErr.Operation = string(op)
+		hubErr.Cause = err
+		return hubErr
+	}
+
+	var tooLargeErr *utils.ResponseTooLargeError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		code = ErrorCodeTimeout
+	case errors.As(err, &tooLargeErr):
+		code = ErrorCodeResponseTooLarge
+	}
+	return &NotificationHubError{
+		Code:      code,
+		Message:   err.Error(),
+		Operation: string(op),
+		Cause:     err,
+	}
+}
+
 // ValidationError represents input validation errors
 type ValidationError struct {
 	Field   string
@@ -225,4 +303,3 @@ func NewMultiError() *MultiError {
 		Errors: make([]error, 0),
 	}
 }
- 
\ No newline at end of file
@@ -0,0 +1,242 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+func statusErrorResponse(code int, header http.Header, body string) ([]byte, *http.Response, error) {
+	if header == nil {
+		header = http.Header{}
+	}
+	resp := &http.Response{StatusCode: code, Header: header}
+	return nil, resp, &utils.StatusError{Response: resp, Body: []byte(body)}
+}
+
+func Test_ErrorsAreTyped(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		header                         = http.Header{}
+	)
+	header.Set("TrackingId", "tracking-123")
+	header.Set("Retry-After", "30")
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return statusErrorResponse(http.StatusTooManyRequests, header, "slow down")
+	}
+
+	_, _, err := nhub.Send(context.Background(), notification, nil)
+
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) {
+		t.Fatalf(errfmt, "error type", "*NotificationHubError", err)
+	}
+	if hubErr.Code != ErrorCodeRateLimited {
+		t.Errorf(errfmt, "code", ErrorCodeRateLimited, hubErr.Code)
+	}
+	if hubErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf(errfmt, "status code", http.StatusTooManyRequests, hubErr.StatusCode)
+	}
+	if hubErr.TrackingID != "tracking-123" {
+		t.Errorf(errfmt, "tracking ID", "tracking-123", hubErr.TrackingID)
+	}
+	if hubErr.RetryAfter != 30*time.Second {
+		t.Errorf(errfmt, "retry after", 30*time.Second, hubErr.RetryAfter)
+	}
+	if hubErr.Operation != "Send" {
+		t.Errorf(errfmt, "operation", "Send", hubErr.Operation)
+	}
+	if hubErr.Details != "slow down" {
+		t.Errorf(errfmt, "details", "slow down", hubErr.Details)
+	}
+	if !hubErr.IsRetryable() {
+		t.Errorf(errfmt, "retryable", true, false)
+	}
+}
+
+func Test_ErrorsSharedErrorIsNotModified(t *testing.T) {
+	var nhub, notification, mockClient = initNotificationTestItems()
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return nil, nil, ErrCircuitOpen
+	}
+
+	_, _, err := nhub.Send(context.Background(), notification, nil)
+
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Operation != "Send" {
+		t.Errorf(errfmt, "error", "Send operation", err)
+	}
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf(errfmt, "errors.Is", ErrCircuitOpen, err)
+	}
+	if ErrCircuitOpen.Operation != "" {
+		t.Errorf(errfmt, "ErrCircuitOpen operation", "", ErrCircuitOpen.Operation)
+	}
+}
+
+func Test_ErrorsNotFoundCodePerOperation(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		ctx              = context.Background()
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return statusErrorResponse(http.StatusNotFound, nil, "")
+	}
+
+	testCases := []struct {
+		name     string
+		call     func() error
+		expected ErrorCode
+	}{
+		{
+			name:     "Installation",
+			call:     func() error { _, _, err := nhub.Installation(ctx, "id"); return err },
+			expected: ErrorCodeInstallationNotFound,
+		},
+		{
+			name:     "Uninstall",
+			call:     func() error { return nhub.Uninstall(ctx, "id") },
+			expected: ErrorCodeInstallationNotFound,
+		},
+		{
+			name:     "Update",
+			call:     func() error { return nhub.Update(ctx, "id", AddTag("tag")) },
+			expected: ErrorCodeInstallationNotFound,
+		},
+		{
+			name:     "Registration",
+			call:     func() error { _, _, err := nhub.Registration(ctx, "id"); return err },
+			expected: ErrorCodeRegistrationNotFound,
+		},
+		{
+			name:     "Unregister",
+			call:     func() error { return nhub.Unregister(ctx, RegisteredDevice{RegistrationID: "id"}) },
+			expected: ErrorCodeRegistrationNotFound,
+		},
+		{
+			name:     "NotificationDetails",
+			call:     func() error { _, _, err := nhub.NotificationDetails(ctx, "id"); return err },
+			expected: ErrorCodeNotificationNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var hubErr *NotificationHubError
+			if err := tc.call(); !errors.As(err, &hubErr) {
+				t.Fatalf(errfmt, "error type", "*NotificationHubError", err)
+			}
+			if hubErr.Code != tc.expected {
+				t.Errorf(errfmt, "code", tc.expected, hubErr.Code)
+			}
+			if hubErr.Operation != tc.name {
+				t.Errorf(errfmt, "operation", tc.name, hubErr.Operation)
+			}
+		})
+	}
+}
+
+func Test_ErrorsWrapNonHTTPFailures(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		transportErr                   = errors.New("connection reset")
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return nil, nil, transportErr
+	}
+
+	var hubErr *NotificationHubError
+	_, _, err := nhub.SendDirect(context.Background(), notification, "handle")
+	if !errors.As(err, &hubErr) {
+		t.Fatalf(errfmt, "error type", "*NotificationHubError", err)
+	}
+	if hubErr.Code != ErrorCodeRequestFailed {
+		t.Errorf(errfmt, "code", ErrorCodeRequestFailed, hubErr.Code)
+	}
+	if !errors.Is(err, transportErr) {
+		t.Errorf(errfmt, "cause", transportErr, hubErr.Cause)
+	}
+
+	_, _, err = nhub.Schedule(context.Background(), notification, nil, time.Now().Add(-time.Minute))
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeInvalidRequest {
+		t.Errorf(errfmt, "schedule error", ErrorCodeInvalidRequest, err)
+	}
+
+	_, _, err = nhub.Register(context.Background(), Registration{NotificationFormat: WindowsPhoneFormat})
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeInvalidRegistration {
+		t.Errorf(errfmt, "register error", ErrorCodeInvalidRegistration, err)
+	}
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return []byte("not xml"), nil, nil
+	}
+	_, _, err = nhub.Registrations(context.Background())
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeInvalidResponse {
+		t.Errorf(errfmt, "registrations error", ErrorCodeInvalidResponse, err)
+	}
+}
+
+func Test_ErrorsFromHubHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("TrackingId", "tracking-456")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("invalid token"))
+	}))
+	defer server.Close()
+
+	var (
+		nhub            = NewNotificationHub("Endpoint="+server.URL+"/;SharedAccessKeyName=name;SharedAccessKey=key", hubPath)
+		notification, _ = NewNotification(Template, []byte("{}"))
+	)
+
+	var hubErr *NotificationHubError
+	_, _, err := nhub.Send(context.Background(), notification, nil)
+	if !errors.As(err, &hubErr) {
+		t.Fatalf(errfmt, "error type", "*NotificationHubError", err)
+	}
+	if !hubErr.IsAuthenticationError() {
+		t.Errorf(errfmt, "authentication error", true, hubErr.Code)
+	}
+	if hubErr.TrackingID != "tracking-456" {
+		t.Errorf(errfmt, "tracking ID", "tracking-456", hubErr.TrackingID)
+	}
+	if !strings.Contains(err.Error(), "invalid token") {
+		t.Errorf(errfmt, "error message", "invalid token", err.Error())
+	}
+}
+
+func TestNewErrorFromHTTPResponse(t *testing.T) {
+	testCases := []struct {
+		status   int
+		expected ErrorCode
+	}{
+		{status: http.StatusBadRequest, expected: ErrorCodeInvalidRequest},
+		{status: http.StatusUnauthorized, expected: ErrorCodeUnauthorized},
+		{status: http.StatusForbidden, expected: ErrorCodeAuthenticationFailed},
+		{status: http.StatusNotFound, expected: ErrorCodeRegistrationNotFound},
+		{status: http.StatusConflict, expected: ErrorCodeInvalidRequest},
+		{status: http.StatusRequestEntityTooLarge, expected: ErrorCodePayloadTooLarge},
+		{status: http.StatusTooManyRequests, expected: ErrorCodeRateLimited},
+		{status: http.StatusInternalServerError, expected: ErrorCodeServerError},
+		{status: http.StatusBadGateway, expected: ErrorCodeServerError},
+		{status: http.StatusServiceUnavailable, expected: ErrorCodeServiceUnavailable},
+		{status: http.StatusGatewayTimeout, expected: ErrorCodeTimeout},
+	}
+
+	for _, tc := range testCases {
+		err := NewErrorFromHTTPResponse(&http.Response{StatusCode: tc.status, Header: http.Header{}}, nil)
+		if err.Code != tc.expected {
+			t.Errorf("NewErrorFromHTTPResponse(%d).Code = %s; want %s", tc.status, err.Code, tc.expected)
+		}
+	}
+}
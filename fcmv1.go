@@ -0,0 +1,226 @@
+package notificationhubs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FcmV1MaxPayloadSize is the largest message FCM accepts, in bytes
+const FcmV1MaxPayloadSize = 4096
+
+// FcmV1MaxTTL is the longest time FCM keeps a message for an offline device
+const FcmV1MaxTTL = 28 * 24 * time.Hour
+
+// Delivery priorities of an Android message
+const (
+	FcmV1PriorityNormal FcmV1AndroidPriority = "NORMAL"
+	FcmV1PriorityHigh   FcmV1AndroidPriority = "HIGH"
+)
+
+var (
+	// fcmV1ReservedDataKeys may not be used as data keys
+	fcmV1ReservedDataKeys = []string{"from", "notification", "message_type"}
+
+	// fcmV1ColorPattern matches the #rrggbb colors of Android notifications
+	fcmV1ColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+type (
+	// FcmV1Message is a typed FCM v1 message. The hub sets the target device,
+	// so the message has no token, topic or condition.
+	FcmV1Message struct {
+		Notification *FcmV1Notification `json:"notification,omitempty"`
+		// Data is delivered to the app, FCM only accepts string values
+		Data    map[string]string   `json:"data,omitempty"`
+		Android *FcmV1AndroidConfig `json:"android,omitempty"`
+		Apns    *FcmV1ApnsConfig    `json:"apns,omitempty"`
+		Webpush *FcmV1WebpushConfig `json:"webpush,omitempty"`
+	}
+
+	// FcmV1Notification is the notification shown on all platforms
+	FcmV1Notification struct {
+		Title string `json:"title,omitempty"`
+		Body  string `json:"body,omitempty"`
+		Image string `json:"image,omitempty"`
+	}
+
+	// FcmV1AndroidConfig holds the Android specific options of a message
+	FcmV1AndroidConfig struct {
+		CollapseKey string
+		Priority    FcmV1AndroidPriority
+		// TTL is how long FCM keeps the message for an offline device, up to FcmV1MaxTTL
+		TTL                   time.Duration
+		RestrictedPackageName string
+		// Data overrides the message data on Android
+		Data         map[string]string
+		Notification *FcmV1AndroidNotification
+		// DirectBootOK delivers the message while the device is in direct boot mode
+		DirectBootOK bool
+	}
+
+	// FcmV1AndroidNotification is the notification shown on Android devices
+	FcmV1AndroidNotification struct {
+		Title       string `json:"title,omitempty"`
+		Body        string `json:"body,omitempty"`
+		Icon        string `json:"icon,omitempty"`
+		Color       string `json:"color,omitempty"`
+		Sound       string `json:"sound,omitempty"`
+		Tag         string `json:"tag,omitempty"`
+		ClickAction string `json:"click_action,omitempty"`
+		ChannelID   string `json:"channel_id,omitempty"`
+		Image       string `json:"image,omitempty"`
+	}
+
+	// FcmV1ApnsConfig holds the APNs specific options of a message
+	FcmV1ApnsConfig struct {
+		Headers map[string]string `json:"headers,omitempty"`
+		// Payload is merged into the notification, so unlike a standalone payload it needs no alert, badge or sound
+		Payload *ApnsPayload `json:"payload,omitempty"`
+	}
+
+	// FcmV1WebpushConfig holds the Web Push specific options of a message
+	FcmV1WebpushConfig struct {
+		Headers map[string]string `json:"headers,omitempty"`
+		Data    map[string]string `json:"data,omitempty"`
+		// Notification holds Web Notification options such as title, body and icon
+		Notification map[string]interface{}  `json:"notification,omitempty"`
+		FcmOptions   *FcmV1WebpushFcmOptions `json:"fcm_options,omitempty"`
+	}
+
+	// FcmV1WebpushFcmOptions are the FCM options of a Web Push message
+	FcmV1WebpushFcmOptions struct {
+		// Link is opened when the notification is clicked, it must be an https URL
+		Link string `json:"link,omitempty"`
+	}
+
+	// FcmV1AndroidPriority is the delivery priority of an Android message
+	FcmV1AndroidPriority string
+
+	// androidConfigJSON is the wire format of FcmV1AndroidConfig
+	androidConfigJSON struct {
+		CollapseKey           string                    `json:"collapse_key,omitempty"`
+		Priority              FcmV1AndroidPriority      `json:"priority,omitempty"`
+		TTL                   string                    `json:"ttl,omitempty"`
+		RestrictedPackageName string                    `json:"restricted_package_name,omitempty"`
+		Data                  map[string]string         `json:"data,omitempty"`
+		Notification          *FcmV1AndroidNotification `json:"notification,omitempty"`
+		DirectBootOK          bool                      `json:"direct_boot_ok,omitempty"`
+	}
+)
+
+// NewFcmV1Message returns a message showing a notification with title and body
+func NewFcmV1Message(title, body string) *FcmV1Message {
+	return &FcmV1Message{Notification: &FcmV1Notification{Title: title, Body: body}}
+}
+
+// Build validates the message and returns it as an FcmV1Format notification
+func (m *FcmV1Message) Build() (*Notification, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(struct {
+		Message *FcmV1Message `json:"message"`
+	}{m})
+	if err != nil {
+		return nil, NewErrorWithCause(ErrorCodeInvalidPayload, "cannot encode FCM v1 message", err)
+	}
+	if len(payload) > FcmV1MaxPayloadSize {
+		return nil, NewError(ErrorCodePayloadTooLarge, fmt.Sprintf("FCM v1 message is %d bytes, the limit is %d", len(payload), FcmV1MaxPayloadSize))
+	}
+	return newNotification(FcmV1Format, payload)
+}
+
+// Validate reports the first invalid field as a *ValidationError
+func (m *FcmV1Message) Validate() error {
+	if m.Notification == nil && m.Data == nil && m.Android == nil && m.Apns == nil && m.Webpush == nil {
+		return NewValidationError("message", "needs a notification, data or platform configuration", nil)
+	}
+	if err := validateFcmV1Data("message.data", m.Data); err != nil {
+		return err
+	}
+	if m.Android != nil {
+		if err := m.Android.validate(); err != nil {
+			return err
+		}
+	}
+	if m.Apns != nil && m.Apns.Payload != nil {
+		if err := m.Apns.Payload.validateOverride(); err != nil {
+			return err
+		}
+	}
+	if m.Webpush != nil {
+		if err := m.Webpush.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the TTL as a duration such as "3600s"
+func (c FcmV1AndroidConfig) MarshalJSON() ([]byte, error) {
+	android := androidConfigJSON{
+		CollapseKey:           c.CollapseKey,
+		Priority:              c.Priority,
+		RestrictedPackageName: c.RestrictedPackageName,
+		Data:                  c.Data,
+		Notification:          c.Notification,
+		DirectBootOK:          c.DirectBootOK,
+	}
+	if c.TTL > 0 {
+		android.TTL = strconv.FormatFloat(c.TTL.Seconds(), 'f', -1, 64) + "s"
+	}
+	return json.Marshal(android)
+}
+
+// validate checks the priority, TTL, data and notification color
+func (c *FcmV1AndroidConfig) validate() error {
+	switch c.Priority {
+	case "", FcmV1PriorityNormal, FcmV1PriorityHigh:
+	default:
+		return NewValidationError("message.android.priority", "must be NORMAL or HIGH", c.Priority)
+	}
+	if c.TTL < 0 || c.TTL > FcmV1MaxTTL {
+		return NewValidationError("message.android.ttl", "must be between 0 and 28 days", c.TTL)
+	}
+	if err := validateFcmV1Data("message.android.data", c.Data); err != nil {
+		return err
+	}
+	if c.Notification != nil && c.Notification.Color != "" && !fcmV1ColorPattern.MatchString(c.Notification.Color) {
+		return NewValidationError("message.android.notification.color", "must be a #rrggbb color", c.Notification.Color)
+	}
+	return nil
+}
+
+// validate checks the Web Push data and link
+func (c *FcmV1WebpushConfig) validate() error {
+	if err := validateFcmV1Data("message.webpush.data", c.Data); err != nil {
+		return err
+	}
+	if c.FcmOptions != nil && c.FcmOptions.Link != "" {
+		if link, err := url.Parse(c.FcmOptions.Link); err != nil || link.Scheme != "https" {
+			return NewValidationError("message.webpush.fcm_options.link", "must be an https URL", c.FcmOptions.Link)
+		}
+	}
+	return nil
+}
+
+// validateFcmV1Data rejects the data keys reserved by FCM
+func validateFcmV1Data(field string, data map[string]string) error {
+	for key := range data {
+		lower := strings.ToLower(key)
+		if key == "" || strings.HasPrefix(lower, "google") || strings.HasPrefix(lower, "gcm") {
+			return NewValidationError(field, "uses a reserved key", key)
+		}
+		for _, reserved := range fcmV1ReservedDataKeys {
+			if key == reserved {
+				return NewValidationError(field, "uses a reserved key", key)
+			}
+		}
+	}
+	return nil
+}
@@ -1,7 +1,12 @@
 package notificationhubs_test
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/koreset/azure-notificationhubs-sdk-go"
 )
@@ -131,3 +136,105 @@ func TestFcmV1NotificationFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestFcmV1MessageNotification(t *testing.T) {
+	message := NewFcmV1Message("Order shipped", "Your order is on its way")
+	message.Data = map[string]string{"orderId": "42"}
+	message.Android = &FcmV1AndroidConfig{
+		CollapseKey:  "orders",
+		Priority:     FcmV1PriorityHigh,
+		TTL:          90 * time.Minute,
+		DirectBootOK: true,
+		Notification: &FcmV1AndroidNotification{ChannelID: "orders", Color: "#FF0000"},
+	}
+	message.Apns = &FcmV1ApnsConfig{
+		Headers: map[string]string{"apns-priority": "5"},
+		Payload: &ApnsPayload{Aps: ApnsAps{ContentAvailable: true}},
+	}
+	message.Webpush = &FcmV1WebpushConfig{FcmOptions: &FcmV1WebpushFcmOptions{Link: "https://example.com/orders/42"}}
+
+	notification, err := message.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if notification.Format != FcmV1Format {
+		t.Errorf("Expected format to be FcmV1Format, got %s", notification.Format)
+	}
+
+	var got, want map[string]interface{}
+	_ = json.Unmarshal(notification.Payload, &got)
+	_ = json.Unmarshal([]byte(`{"message": {
+		"notification": {"title": "Order shipped", "body": "Your order is on its way"},
+		"data": {"orderId": "42"},
+		"android": {
+			"collapse_key": "orders",
+			"priority": "HIGH",
+			"ttl": "5400s",
+			"direct_boot_ok": true,
+			"notification": {"channel_id": "orders", "color": "#FF0000"}
+		},
+		"apns": {"headers": {"apns-priority": "5"}, "payload": {"aps": {"content-available": 1}}},
+		"webpush": {"fcm_options": {"link": "https://example.com/orders/42"}}
+	}}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() payload = %s", notification.Payload)
+	}
+}
+
+func TestFcmV1MessageValidation(t *testing.T) {
+	negativeBadge := -1
+	testCases := []struct {
+		name    string
+		message FcmV1Message
+		field   string
+	}{
+		{name: "empty message", message: FcmV1Message{}, field: "message"},
+		{name: "reserved data key", message: FcmV1Message{Data: map[string]string{"from": "x"}}, field: "message.data"},
+		{name: "google prefixed data key", message: FcmV1Message{Data: map[string]string{"google.sent_time": "1"}}, field: "message.data"},
+		{name: "reserved android data key", message: FcmV1Message{Android: &FcmV1AndroidConfig{Data: map[string]string{"gcm.x": "1"}}}, field: "message.android.data"},
+		{name: "unknown priority", message: FcmV1Message{Android: &FcmV1AndroidConfig{Priority: "URGENT"}}, field: "message.android.priority"},
+		{name: "ttl too long", message: FcmV1Message{Android: &FcmV1AndroidConfig{TTL: FcmV1MaxTTL + time.Second}}, field: "message.android.ttl"},
+		{name: "malformed color", message: FcmV1Message{Android: &FcmV1AndroidConfig{Notification: &FcmV1AndroidNotification{Color: "red"}}}, field: "message.android.notification.color"},
+		{name: "invalid apns payload", message: FcmV1Message{Apns: &FcmV1ApnsConfig{Payload: &ApnsPayload{Aps: ApnsAps{Badge: &negativeBadge}}}}, field: "aps.badge"},
+		{name: "reserved apns custom key", message: FcmV1Message{Apns: &FcmV1ApnsConfig{Payload: &ApnsPayload{Custom: map[string]interface{}{"aps": 1}}}}, field: "Custom"},
+		{name: "insecure webpush link", message: FcmV1Message{Webpush: &FcmV1WebpushConfig{FcmOptions: &FcmV1WebpushFcmOptions{Link: "http://example.com"}}}, field: "message.webpush.fcm_options.link"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.message.Build()
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tc.field {
+				t.Errorf("Build() error = %v; want validation error for %s", err, tc.field)
+			}
+		})
+	}
+}
+
+func TestFcmV1ApnsOverrideWithoutAlert(t *testing.T) {
+	message := NewFcmV1Message("Order shipped", "Your order is on its way")
+	message.Apns = &FcmV1ApnsConfig{Payload: &ApnsPayload{
+		Aps:    ApnsAps{Category: "ORDER", MutableContent: true},
+		Custom: map[string]interface{}{"orderId": "42"},
+	}}
+
+	notification, err := message.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if want := `"payload":{"aps":{"category":"ORDER","mutable-content":1},"orderId":"42"}`; !strings.Contains(string(notification.Payload), want) {
+		t.Errorf("payload = %s; want it to contain %s", notification.Payload, want)
+	}
+}
+
+func TestFcmV1MessageTooLarge(t *testing.T) {
+	message := &FcmV1Message{Data: map[string]string{"blob": strings.Repeat("x", FcmV1MaxPayloadSize)}}
+
+	_, err := message.Build()
+
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodePayloadTooLarge {
+		t.Errorf("Build() error = %v; want %s", err, ErrorCodePayloadTooLarge)
+	}
+}
@@ -0,0 +1,107 @@
+package notificationhubs
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+type (
+	// hubConfig is the configuration of a hub. A published config is never modified,
+	// every change stores a modified copy, so an operation keeps the config it started with.
+	hubConfig struct {
+		// hubURL is fixed for the lifetime of a hub
+		hubURL *url.URL
+		// hubAPIVersion is the version chosen with WithAPIVersion, empty when none was
+		hubAPIVersion           string
+		operationAPIVersions    map[string]string
+		client                  utils.HTTPClient
+		retryPolicy             *utils.RetryPolicy
+		middlewares             []Middleware
+		rateLimiter             *RateLimiter
+		tracer                  Tracer
+		metrics                 Metrics
+		logger                  *slog.Logger
+		logBodyLimit            int
+		expirationTimeGenerator utils.ExpirationTimeGenerator
+		bearerToken             *bearerTokenCache
+		tokenRenewalSkew        time.Duration
+		apnsTTL                 time.Duration
+		userAgent               string
+	}
+
+	// configContextKey stores the config snapshot of a running operation
+	configContextKey struct{}
+
+	// configSnapshot is the config of an operation of hub
+	configSnapshot struct {
+		hub    *NotificationHub
+		config *hubConfig
+	}
+)
+
+// defaultConfig returns the configuration of a new hub at hubURL
+func defaultConfig(hubURL *url.URL) *hubConfig {
+	return &hubConfig{
+		hubURL:                  hubURL,
+		client:                  utils.NewHubHTTPClient(),
+		expirationTimeGenerator: utils.NewExpirationTimeGenerator(),
+		tokenRenewalSkew:        DefaultTokenRenewalSkew,
+		apnsTTL:                 DefaultApnsTTL,
+	}
+}
+
+// With returns a new hub with the configuration and keys of h, changed by opts.
+// h itself is left unchanged, so requests in flight on h are not affected.
+func (h *NotificationHub) With(opts ...HubOption) *NotificationHub {
+	next := *h.config.Load()
+	for _, opt := range opts {
+		opt(&next)
+	}
+
+	n := &NotificationHub{
+		SasKeyName:  h.SasKeyName,
+		SasKeyValue: h.SasKeyValue,
+		HubURL:      cloneURL(next.hubURL),
+	}
+	n.config.Store(&next)
+	n.keys.configured, n.keys.provider, n.keys.primary, n.keys.secondary, n.keys.active = h.keys.state()
+	return n
+}
+
+// update atomically replaces the configuration by a copy changed by opts
+func (h *NotificationHub) update(opts ...HubOption) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	next := *h.config.Load()
+	for _, opt := range opts {
+		opt(&next)
+	}
+	h.config.Store(&next)
+}
+
+// snapshot returns ctx carrying the current configuration,
+// which the rest of the operation uses even when the hub is reconfigured
+func (h *NotificationHub) snapshot(ctx context.Context) (context.Context, *hubConfig) {
+	if s, ok := ctx.Value(configContextKey{}).(configSnapshot); ok && s.hub == h {
+		return ctx, s.config
+	}
+	cfg := h.config.Load()
+	return context.WithValue(ctx, configContextKey{}, configSnapshot{hub: h, config: cfg}), cfg
+}
+
+// configFrom returns the configuration of the operation running in ctx
+func (h *NotificationHub) configFrom(ctx context.Context) *hubConfig {
+	_, cfg := h.snapshot(ctx)
+	return cfg
+}
+
+// cloneURL returns a copy of u
+func cloneURL(u *url.URL) *url.URL {
+	c := *u
+	return &c
+}
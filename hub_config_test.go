@@ -0,0 +1,137 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+// countingClient returns a client answering 201 and counting its requests
+func countingClient(calls *int32) utils.HTTPClient {
+	return utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+		atomic.AddInt32(calls, 1)
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	})
+}
+
+func Test_ReconfigureDuringSends(t *testing.T) {
+	var (
+		first, second   int32
+		nhub            = NewNotificationHub(connectionString, hubPath, WithHTTPClient(countingClient(&first)))
+		notification, _ = NewNotification(AppleFormat, []byte(`{"aps":{}}`))
+		wg              sync.WaitGroup
+		stop            = make(chan struct{})
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				nhub.SetHTTPClient(countingClient(&second))
+			} else {
+				nhub.SetHTTPClient(countingClient(&first))
+			}
+			nhub.SetExpirationTimeGenerator(utils.NewExpirationTimeGenerator())
+			_ = nhub.With(
+				WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+				WithApnsTTL(time.Duration(i)*time.Minute),
+				WithMetrics(newMockMetrics()),
+				WithTokenLifetime(time.Hour),
+				WithLogBodies(i%100),
+			)
+		}
+	}()
+
+	var senders sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			for j := 0; j < 50; j++ {
+				if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+					t.Errorf(errfmt, "error", nil, err)
+				}
+			}
+		}()
+	}
+	senders.Wait()
+	close(stop)
+	wg.Wait()
+
+	if total := atomic.LoadInt32(&first) + atomic.LoadInt32(&second); total != 400 {
+		t.Errorf(errfmt, "requests", 400, total)
+	}
+}
+
+func Test_InFlightRequestKeepsItsConfig(t *testing.T) {
+	var (
+		entered = make(chan struct{})
+		release = make(chan struct{})
+		old     = utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+			close(entered)
+			<-release
+			return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+		})
+		newCalls int32
+		nhub     = NewNotificationHub(connectionString, hubPath, WithHTTPClient(old), WithUserAgent("old"))
+		done     = make(chan error)
+	)
+
+	go func() {
+		done <- nhub.Uninstall(context.Background(), "id")
+	}()
+	<-entered
+	nhub.SetHTTPClient(countingClient(&newCalls))
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if newCalls != 0 {
+		t.Errorf(errfmt, "requests of the new client", 0, newCalls)
+	}
+	_ = nhub.Uninstall(context.Background(), "id")
+	if newCalls != 1 {
+		t.Errorf(errfmt, "requests of the new client", 1, newCalls)
+	}
+}
+
+func Test_WithReturnsANewHub(t *testing.T) {
+	var (
+		agents     []string
+		mockClient = utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+			agents = append(agents, req.Header.Get("User-Agent"))
+			if req.URL.Host != "testhub-ns.servicebus.windows.net" {
+				t.Errorf(errfmt, "request host", "testhub-ns.servicebus.windows.net", req.URL.Host)
+			}
+			return nil, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+		})
+		nhub    = NewNotificationHub(connectionString, hubPath, WithHTTPClient(mockClient), WithUserAgent("base"))
+		derived = nhub.With(WithUserAgent("derived"))
+	)
+
+	nhub.HubURL.Host = "elsewhere.example.com"
+	_ = nhub.Uninstall(context.Background(), "id")
+	_ = derived.Uninstall(context.Background(), "id")
+
+	if len(agents) != 2 || agents[0] != "base" || agents[1] != "derived" {
+		t.Errorf(errfmt, "user agents", []string{"base", "derived"}, agents)
+	}
+	if derived.HubURL.Host != "testhub-ns.servicebus.windows.net" {
+		t.Errorf(errfmt, "derived hub URL", "testhub-ns.servicebus.windows.net", derived.HubURL.Host)
+	}
+}
@@ -10,21 +10,29 @@ import (
 
 // Installation reads one specific installation
 func (h *NotificationHub) Installation(ctx context.Context, installationID string) (raw []byte, installation *Installation, err error) {
+	ctx, span := h.startSpan(ctx, opInstallation)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		instURL = h.generateAPIURL(path.Join("installations", installationID))
 	)
 
-	raw, _, err = h.exec(ctx, getMethod, instURL, Headers{}, nil)
+	raw, _, err = h.exec(ctx, opInstallation, getMethod, instURL, Headers{}, nil)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(raw, &installation)
+	if err = json.Unmarshal(raw, &installation); err != nil {
+		err = newOperationError(opInstallation, ErrorCodeInvalidResponse, err)
+	}
 	return
 }
 
 // Install sends a device installation to the Azure hub
 func (h *NotificationHub) Install(ctx context.Context, installation Installation) (err error) {
+	ctx, span := h.startSpan(ctx, opInstall)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		instURL = h.generateAPIURL(path.Join("installations", installation.InstallationID))
 		headers = map[string]string{
@@ -32,17 +40,23 @@ func (h *NotificationHub) Install(ctx context.Context, installation Installation
 		}
 	)
 
+	if !installation.Platform.IsValid() {
+		return newOperationError(opInstall, ErrorCodeInvalidInstallation, fmt.Errorf("unknown installation platform '%s'", installation.Platform))
+	}
 	raw, err := json.Marshal(installation)
 	if err != nil {
-		return
+		return newOperationError(opInstall, ErrorCodeInvalidInstallation, err)
 	}
 
-	_, _, err = h.exec(ctx, putMethod, instURL, headers, bytes.NewBuffer(raw))
+	_, _, err = h.exec(withAPIFeature(ctx, installation.Platform.apiFeature()), opInstall, putMethod, instURL, headers, bytes.NewBuffer(raw))
 	return
 }
 
 // Update sends a collection of installation changes to the Azure hub
 func (h *NotificationHub) Update(ctx context.Context, installationID string, changes ...InstallationChange) (err error) {
+	ctx, span := h.startSpan(ctx, opUpdate)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		instURL = h.generateAPIURL(path.Join("installations", installationID))
 		headers = map[string]string{
@@ -52,10 +66,10 @@ func (h *NotificationHub) Update(ctx context.Context, installationID string, cha
 
 	raw, err := json.Marshal(changes)
 	if err != nil {
-		return
+		return newOperationError(opUpdate, ErrorCodeInvalidInstallation, err)
 	}
 
-	_, _, err = h.exec(ctx, patchMethod, instURL, headers, bytes.NewBuffer(raw))
+	_, _, err = h.exec(ctx, opUpdate, patchMethod, instURL, headers, bytes.NewBuffer(raw))
 	return
 }
 
@@ -202,6 +216,9 @@ func RemoveSecondaryTile(name string) InstallationChange {
 
 // Uninstall sends a device installation delete to the Azure hub
 func (h *NotificationHub) Uninstall(ctx context.Context, installationID string) (err error) {
+	ctx, span := h.startSpan(ctx, opUninstall)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		instURL = h.generateAPIURL(path.Join("installations", installationID))
 		headers = map[string]string{
@@ -209,6 +226,6 @@ func (h *NotificationHub) Uninstall(ctx context.Context, installationID string)
 		}
 	)
 
-	_, _, err = h.exec(ctx, deleteMethod, instURL, headers, nil)
+	_, _, err = h.exec(ctx, opUninstall, deleteMethod, instURL, headers, nil)
 	return
 }
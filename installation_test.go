@@ -27,7 +27,7 @@ func Test_Install(t *testing.T) {
 		if gotMethod != putMethod {
 			t.Errorf(errfmt, "method", putMethod, gotMethod)
 		}
-		u, _ := url.Parse(installationsURL)
+		u, _ := url.Parse(fcmV1InstallationsURL)
 		u.Path += "/" + installation.InstallationID
 		wantURL := u.String()
 		gotURL := req.URL.String()
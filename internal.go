@@ -15,7 +15,13 @@ const (
 	// Current telemetry API version (same as latest service API)
 	telemetryAPIVersionValue = "2016-07"
 
+	// Oldest API version supporting FCM v1 sends, registrations and installations
+	fcmV1APIVersionValue = "2020-06"
+
 	directParam = "direct"
+
+	// Header holding the token of the next page of a registrations feed
+	continuationTokenHeader = "X-MS-ContinuationToken"
 )
 
 // API version helpers
@@ -31,13 +37,6 @@ const (
 	DefaultAPIVersion = LatestAPIVersion
 )
 
-// GetAPIVersionForOperation returns the latest API version for all operations
-// All operations now use 2016-07 for enhanced features and better error reporting
-func GetAPIVersionForOperation(operationType string) string {
-	// All operations use the latest API version for consistency and enhanced features
-	return LatestAPIVersion
-}
-
 // Internal constants continued
 const (
 	// for connection string parsing
@@ -103,4 +102,56 @@ const (
     </FcmV1TemplateRegistrationDescription>
   </content>
 </entry>`
+
+	// admRegXMLString is the XML string for registering an ADM device
+	// Replace {{Tags}} and {{DeviceID}} with the correct values
+	admRegXMLString string = `<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://www.w3.org/2005/Atom">
+  <content type="application/xml">
+    <AdmRegistrationDescription xmlns:i="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+      <Tags>{{Tags}}</Tags>
+      <AdmRegistrationId>{{DeviceID}}</AdmRegistrationId>
+    </AdmRegistrationDescription>
+  </content>
+</entry>`
+
+	// admTemplateRegXMLString is the XML string for registering an ADM device with template
+	// Replace {{Tags}}, {{DeviceID}} and {{Template}} with the correct values
+	admTemplateRegXMLString string = `<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://www.w3.org/2005/Atom">
+  <content type="application/xml">
+    <AdmTemplateRegistrationDescription xmlns:i="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+      <Tags>{{Tags}}</Tags>
+      <AdmRegistrationId>{{DeviceID}}</AdmRegistrationId>
+      <BodyTemplate><![CDATA[{{Template}}]]></BodyTemplate>
+    </AdmTemplateRegistrationDescription>
+  </content>
+</entry>`
+
+	// baiduRegXMLString is the XML string for registering a Baidu device
+	// Replace {{Tags}}, {{BaiduUserID}} and {{DeviceID}}, the channel id, with the correct values
+	baiduRegXMLString string = `<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://www.w3.org/2005/Atom">
+  <content type="application/xml">
+    <BaiduRegistrationDescription xmlns:i="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+      <Tags>{{Tags}}</Tags>
+      <BaiduUserId>{{BaiduUserID}}</BaiduUserId>
+      <BaiduChannelId>{{DeviceID}}</BaiduChannelId>
+    </BaiduRegistrationDescription>
+  </content>
+</entry>`
+
+	// baiduTemplateRegXMLString is the XML string for registering a Baidu device with template
+	// Replace {{Tags}}, {{BaiduUserID}}, {{DeviceID}}, the channel id, and {{Template}} with the correct values
+	baiduTemplateRegXMLString string = `<?xml version="1.0" encoding="utf-8"?>
+<entry xmlns="http://www.w3.org/2005/Atom">
+  <content type="application/xml">
+    <BaiduTemplateRegistrationDescription xmlns:i="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+      <Tags>{{Tags}}</Tags>
+      <BaiduUserId>{{BaiduUserID}}</BaiduUserId>
+      <BaiduChannelId>{{DeviceID}}</BaiduChannelId>
+      <BodyTemplate><![CDATA[{{Template}}]]></BodyTemplate>
+    </BaiduTemplateRegistrationDescription>
+  </content>
+</entry>`
 )
@@ -0,0 +1,157 @@
+package notificationhubs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// KeySlot identifies one of the two shared access keys of a hub
+type KeySlot string
+
+const (
+	// PrimaryKey is the primary shared access key
+	PrimaryKey KeySlot = "primary"
+	// SecondaryKey is the secondary shared access key
+	SecondaryKey KeySlot = "secondary"
+)
+
+type (
+	// SasKey is a named shared access key
+	SasKey struct {
+		Name  string
+		Value string
+	}
+
+	// SasKeyProvider returns the current primary and secondary keys of the hub.
+	// It is called for every request, so it should be cheap.
+	SasKeyProvider func(ctx context.Context) (primary, secondary SasKey, err error)
+
+	// keyRing holds the keys signing hub requests and which of them is active.
+	// Until keys are configured, the primary key is the key of the connection string.
+	keyRing struct {
+		mu         sync.Mutex
+		configured bool
+		primary    SasKey
+		secondary  SasKey
+		provider   SasKeyProvider
+		active     KeySlot
+	}
+
+	// requestAuthorization is the Authorization header of a request and how it was made
+	requestAuthorization struct {
+		header string
+		shared bool
+		slot   KeySlot
+	}
+)
+
+// SetSasKeys makes the hub sign its requests with the primary key, falling back
+// to the secondary key when the hub rejects a request with 401 or 403.
+// It is safe to call at any time to rotate keys without rebuilding the hub.
+func (h *NotificationHub) SetSasKeys(primary, secondary SasKey) {
+	h.keys.mu.Lock()
+	defer h.keys.mu.Unlock()
+	h.keys.configured = true
+	h.keys.primary, h.keys.secondary = primary, secondary
+	h.keys.provider = nil
+	h.keys.active = PrimaryKey
+}
+
+// SetSasKeyProvider makes the hub ask p for its keys on every request,
+// with the same fallback as SetSasKeys
+func (h *NotificationHub) SetSasKeyProvider(p SasKeyProvider) {
+	h.keys.mu.Lock()
+	defer h.keys.mu.Unlock()
+	h.keys.configured = p != nil
+	h.keys.provider = p
+	h.keys.active = PrimaryKey
+}
+
+// ActiveKey reports which key currently signs requests
+func (h *NotificationHub) ActiveKey() KeySlot {
+	_, _, _, _, active := h.keys.state()
+	return active
+}
+
+// sasKey returns the active key and its slot. Without configured keys
+// it is the key of the connection string.
+func (h *NotificationHub) sasKey(ctx context.Context) (SasKey, KeySlot, error) {
+	configured, provider, primary, secondary, slot := h.keys.state()
+	if !configured {
+		return primary, PrimaryKey, nil
+	}
+	if provider != nil {
+		var err error
+		if primary, secondary, err = provider(ctx); err != nil {
+			return SasKey{}, slot, err
+		}
+	}
+	if slot == SecondaryKey {
+		if secondary.Value == "" {
+			return SasKey{}, slot, errors.New("secondary key is not set")
+		}
+		return secondary, slot, nil
+	}
+	return primary, slot, nil
+}
+
+// fallback switches the active key away from failed, reporting whether
+// a request signed with failed should be retried
+func (h *NotificationHub) fallback(ctx context.Context, failed KeySlot) bool {
+	configured, provider, primary, secondary, active := h.keys.state()
+	if !configured {
+		return false
+	}
+	if active != failed {
+		// another request already switched keys
+		return true
+	}
+	if provider != nil {
+		var err error
+		if primary, secondary, err = provider(ctx); err != nil {
+			return false
+		}
+	}
+
+	alternate, other := secondary, SecondaryKey
+	if failed == SecondaryKey {
+		alternate, other = primary, PrimaryKey
+	}
+	if alternate.Value == "" {
+		return false
+	}
+	h.keys.mu.Lock()
+	h.keys.active = other
+	h.keys.mu.Unlock()
+	return true
+}
+
+// keyName returns the name of the active key without calling a key provider
+func (h *NotificationHub) keyName() string {
+	_, provider, primary, secondary, active := h.keys.state()
+	switch {
+	case provider != nil:
+		return ""
+	case active == SecondaryKey:
+		return secondary.Name
+	}
+	return primary.Name
+}
+
+// state returns a consistent copy of the key ring
+func (r *keyRing) state() (configured bool, provider SasKeyProvider, primary, secondary SasKey, active KeySlot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	active = r.active
+	if active == "" {
+		active = PrimaryKey
+	}
+	return r.configured, r.provider, r.primary, r.secondary, active
+}
+
+// isAuthFailure tells whether the hub rejected the credentials of a request
+func isAuthFailure(response *http.Response) bool {
+	return response != nil && (response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden)
+}
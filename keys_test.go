@@ -0,0 +1,183 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+// signingKeyName returns the key name a request was signed with
+func signingKeyName(req *http.Request) string {
+	token, _ := url.ParseQuery(strings.TrimPrefix(req.Header.Get("Authorization"), "SharedAccessSignature "))
+	return token.Get("skn")
+}
+
+// rejectKey answers 401 to requests signed with the named key
+func rejectKey(name string, calls *[]string, bodies *[]string) func(req *http.Request) ([]byte, *http.Response, error) {
+	return func(req *http.Request) ([]byte, *http.Response, error) {
+		*calls = append(*calls, signingKeyName(req))
+		if req.Body != nil && bodies != nil {
+			b, _ := io.ReadAll(req.Body)
+			*bodies = append(*bodies, string(b))
+		}
+		if signingKeyName(req) == name {
+			return statusErrorResponse(http.StatusUnauthorized, nil, "invalid signature")
+		}
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+}
+
+func Test_SasKeyFallback(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		calls, bodies                  []string
+	)
+	nhub.SetSasKeys(SasKey{Name: "old", Value: "oldKey"}, SasKey{Name: "new", Value: "newKey"})
+	mockClient.execFunc = rejectKey("old", &calls, &bodies)
+
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if len(calls) != 2 || calls[0] != "old" || calls[1] != "new" {
+		t.Errorf(errfmt, "signing keys", []string{"old", "new"}, calls)
+	}
+	if len(bodies) != 2 || bodies[1] != string(notification.Payload) {
+		t.Errorf(errfmt, "replayed body", string(notification.Payload), bodies)
+	}
+	if nhub.ActiveKey() != SecondaryKey {
+		t.Errorf(errfmt, "active key", SecondaryKey, nhub.ActiveKey())
+	}
+
+	calls = nil
+	_, _, _ = nhub.Send(context.Background(), notification, nil)
+	if len(calls) != 1 || calls[0] != "new" {
+		t.Errorf(errfmt, "signing keys after fallback", []string{"new"}, calls)
+	}
+
+	// swapping keys at runtime starts over with the new primary key
+	nhub.SetSasKeys(SasKey{Name: "newer", Value: "newerKey"}, SasKey{Name: "new", Value: "newKey"})
+	calls = nil
+	_, _, _ = nhub.Send(context.Background(), notification, nil)
+	if len(calls) != 1 || calls[0] != "newer" || nhub.ActiveKey() != PrimaryKey {
+		t.Errorf(errfmt, "signing keys after swap", []string{"newer"}, calls)
+	}
+}
+
+func Test_SasKeyFallbackRetriesOnce(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		calls            []string
+	)
+	nhub.SetSasKeys(SasKey{Name: "a", Value: "aKey"}, SasKey{Name: "b", Value: "bKey"})
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		calls = append(calls, signingKeyName(req))
+		return statusErrorResponse(http.StatusForbidden, nil, "")
+	}
+
+	err := nhub.Uninstall(context.Background(), "id")
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.StatusCode != http.StatusForbidden {
+		t.Errorf(errfmt, "error", http.StatusForbidden, err)
+	}
+	if len(calls) != 2 {
+		t.Errorf(errfmt, "attempts", 2, calls)
+	}
+}
+
+func Test_SasKeyNoFallbackWithoutSecondary(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		calls            []string
+	)
+	mockClient.execFunc = rejectKey(nhub.SasKeyName, &calls, nil)
+
+	err := nhub.Uninstall(context.Background(), "id")
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeUnauthorized {
+		t.Errorf(errfmt, "error code", ErrorCodeUnauthorized, err)
+	}
+	if len(calls) != 1 || nhub.ActiveKey() != PrimaryKey {
+		t.Errorf(errfmt, "attempts", 1, calls)
+	}
+}
+
+func Test_SasKeyProvider(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		calls            []string
+		mu               sync.Mutex
+		primary          = SasKey{Name: "p1", Value: "p1Key"}
+	)
+	nhub.SetSasKeyProvider(func(ctx context.Context) (SasKey, SasKey, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return primary, SasKey{Name: "s1", Value: "s1Key"}, nil
+	})
+	mockClient.execFunc = rejectKey("p1", &calls, nil)
+
+	if err := nhub.Uninstall(context.Background(), "id"); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if len(calls) != 2 || calls[1] != "s1" || nhub.ActiveKey() != SecondaryKey {
+		t.Errorf(errfmt, "signing keys", []string{"p1", "s1"}, calls)
+	}
+
+	nhub.SetSasKeyProvider(func(ctx context.Context) (SasKey, SasKey, error) {
+		return SasKey{}, SasKey{}, errors.New("vault unavailable")
+	})
+	err := nhub.Uninstall(context.Background(), "id")
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeAuthenticationFailed {
+		t.Errorf(errfmt, "error code", ErrorCodeAuthenticationFailed, err)
+	}
+}
+
+func Test_SasKeySwapConcurrently(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		wg               sync.WaitGroup
+	)
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return nil, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			nhub.SetSasKeys(SasKey{Name: "a", Value: "aKey"}, SasKey{Name: "b", Value: "bKey"})
+		}()
+		go func() {
+			defer wg.Done()
+			_ = nhub.Uninstall(context.Background(), "id")
+			_ = nhub.ActiveKey()
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_SasKeyFieldsAreCopies(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		tokens           []string
+	)
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		tokens = append(tokens, req.Header.Get("Authorization"))
+		return nil, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}
+
+	_ = nhub.Uninstall(context.Background(), "id")
+	nhub.SasKeyName, nhub.SasKeyValue = "changed", "changedKey"
+	_ = nhub.Uninstall(context.Background(), "id")
+
+	if tokens[0] != tokens[1] {
+		t.Errorf(errfmt, "token after changing the key fields", tokens[0], tokens[1])
+	}
+}
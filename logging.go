@@ -0,0 +1,98 @@
+package notificationhubs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+var (
+	// secretPatterns match secrets in logged strings, the first group is kept
+	secretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(sig=)[^&\s"';]+`),
+		regexp.MustCompile(`(?i)(SharedAccessKey=)[^;\s"']+`),
+		regexp.MustCompile(`(?i)(SharedAccessSignature )[^\s"']+`),
+		regexp.MustCompile(`(?i)(Bearer )[^\s"']+`),
+		regexp.MustCompile(`(<(?:DeviceToken|FcmV1RegistrationId|ChannelUri|AdmRegistrationId|BaiduUserId|BaiduChannelId)>)[^<]*`),
+		regexp.MustCompile(`("pushChannel"\s*:\s*")[^"]*`),
+		regexp.MustCompile(`("path"\s*:\s*"[^"]*pushChannel"\s*,\s*"value"\s*:\s*")(?:[^"\\]|\\.)*`),
+		regexp.MustCompile(`(name=devices\r?\n(?:[^\r\n]+\r?\n)*\r?\n)\[[^\]]*\]?`),
+	}
+)
+
+// LogValue implements slog.LogValuer so a logged hub never reveals its key
+func (h *NotificationHub) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("url", redactSecrets(h.config.Load().hubURL.String())),
+		slog.String("keyName", h.keyName()),
+	)
+}
+
+// logRequest logs a finished hub request
+func (cfg *hubConfig) logRequest(ctx context.Context, op operation, req *http.Request, raw []byte, response *http.Response, err error, duration time.Duration) {
+	if cfg.logger == nil {
+		return
+	}
+
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if !cfg.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", string(op)),
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Duration("duration", duration),
+	}
+	if response != nil {
+		attrs = append(attrs,
+			slog.Int("status", response.StatusCode),
+			slog.String("trackingId", response.Header.Get("TrackingId")),
+		)
+	}
+	if cfg.logBodyLimit > 0 {
+		if req.GetBody != nil {
+			if body, berr := req.GetBody(); berr == nil {
+				b, _ := io.ReadAll(io.LimitReader(body, int64(cfg.logBodyLimit)+1))
+				_ = body.Close()
+				attrs = append(attrs, slog.String("requestBody", cfg.truncateBody(b)))
+			}
+		}
+		if len(raw) > 0 {
+			attrs = append(attrs, slog.String("responseBody", cfg.truncateBody(raw)))
+		}
+	}
+
+	msg := "notification hub request"
+	if err != nil {
+		msg = "notification hub request failed"
+		attrs = append(attrs, slog.String("error", redactSecrets(err.Error())))
+	}
+	cfg.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// truncateBody redacts and truncates a logged body
+func (cfg *hubConfig) truncateBody(b []byte) string {
+	s := redactSecrets(string(b))
+	if len(s) > cfg.logBodyLimit {
+		return s[:cfg.logBodyLimit] + "...(truncated)"
+	}
+	return s
+}
+
+// redactSecrets removes signatures, keys and device handles from s
+func redactSecrets(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+redacted)
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package notificationhubs_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func initLoggingTestItems(level slog.Level) (*NotificationHub, *mockHubHTTPClient, *bytes.Buffer) {
+	var (
+		nhub, mockClient = initTestItems()
+		out              = &bytes.Buffer{}
+	)
+	nhub = nhub.With(WithLogger(slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level}))))
+	return nhub, mockClient, out
+}
+
+func Test_LoggingRequests(t *testing.T) {
+	var (
+		nhub, mockClient, out = initLoggingTestItems(slog.LevelDebug)
+		notification, _       = NewNotification(AppleFormat, []byte(`{"aps":{"alert":"hi"}}`))
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		header := http.Header{}
+		header.Set("TrackingId", "tracking-123")
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: header}, nil
+	}
+
+	if _, _, err := nhub.SendDirect(context.Background(), notification, "secret-device-handle"); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	logged := out.String()
+	for _, want := range []string{`"level":"DEBUG"`, `"method":"POST"`, `"path":"/testhub/messages"`, `"status":201`, `"trackingId":"tracking-123"`, `"operation":"SendDirect"`, `"duration":`} {
+		if !strings.Contains(logged, want) {
+			t.Errorf(errfmt, "log field", want, logged)
+		}
+	}
+	for _, secret := range []string{"secret-device-handle", "SharedAccessSignature", "sig=", "testAccessKey\"", "requestBody"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf(errfmt, "redacted log", "no "+secret, logged)
+		}
+	}
+}
+
+func Test_LoggingFailuresAtWarn(t *testing.T) {
+	var nhub, mockClient, out = initLoggingTestItems(slog.LevelWarn)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return statusErrorResponse(http.StatusUnauthorized, nil, "token SharedAccessSignature sr=x&sig=abc123&se=1 rejected")
+	}
+
+	_, _, _ = nhub.Installation(context.Background(), "id")
+
+	logged := out.String()
+	if !strings.Contains(logged, `"level":"WARN"`) || !strings.Contains(logged, `"status":401`) {
+		t.Errorf(errfmt, "warn log", "WARN 401", logged)
+	}
+	if strings.Contains(logged, "abc123") {
+		t.Errorf(errfmt, "redacted signature", "no abc123", logged)
+	}
+}
+
+func Test_LoggingBodies(t *testing.T) {
+	var (
+		nhub, mockClient, out = initLoggingTestItems(slog.LevelDebug)
+		notification, _       = NewNotification(Template, []byte(`{"message":"`+strings.Repeat("x", 100)+`"}`))
+	)
+	nhub = nhub.With(WithLogBodies(4096))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return []byte("<DeviceToken>ABCDEF</DeviceToken>"), &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}
+
+	if _, _, err := nhub.SendDirectBatch(context.Background(), notification, "handle-one", "handle-two"); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	nhub = nhub.With(WithLogBodies(32))
+	_, _, _ = nhub.Send(context.Background(), notification, nil)
+
+	logged := out.String()
+	if !strings.Contains(logged, `"requestBody":"{\"message\":\"xxxxxxxxxxxxxxxxxxxx...(truncated)"`) {
+		t.Errorf(errfmt, "truncated body", "32 bytes", logged)
+	}
+	if !strings.Contains(logged, "<DeviceToken>REDACTED</DeviceToken>") || !strings.Contains(logged, `name=devices\r\nContent-Type: application/json\r\n\r\nREDACTED`) {
+		t.Errorf(errfmt, "redacted response body", "REDACTED", logged)
+	}
+	for _, secret := range []string{"handle-one", "ABCDEF"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf(errfmt, "redacted log", "no "+secret, logged)
+		}
+	}
+}
+
+func Test_LoggingRedactsPushChannelPatches(t *testing.T) {
+	var nhub, mockClient, out = initLoggingTestItems(slog.LevelDebug)
+	nhub = nhub.With(WithLogBodies(4096))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return nil, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}
+
+	if err := nhub.Update(context.Background(), "id", SetPushChannel("secret-handle"), SetSecondaryTilePushChannel("tile", "secret-tile-handle"), AddTag("visible-tag")); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	logged := out.String()
+	for _, secret := range []string{"secret-handle", "secret-tile-handle"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf(errfmt, "redacted log", "no "+secret, logged)
+		}
+	}
+	if !strings.Contains(logged, `\"path\":\"/pushChannel\",\"value\":\"REDACTED\"`) || !strings.Contains(logged, "visible-tag") {
+		t.Errorf(errfmt, "logged patch", "redacted push channels only", logged)
+	}
+}
+
+func Test_LoggingHubValue(t *testing.T) {
+	var (
+		nhub, _ = initTestItems()
+		out     = &bytes.Buffer{}
+	)
+	slog.New(slog.NewTextHandler(out, nil)).Info("hub", "hub", nhub)
+
+	if strings.Contains(out.String(), "testAccessKey ") || strings.Contains(out.String(), "testAccessKey}") {
+		t.Errorf(errfmt, "logged hub", "no key", out.String())
+	}
+	if !strings.Contains(out.String(), "keyName=testAccessKeyName") {
+		t.Errorf(errfmt, "logged hub", "key name", out.String())
+	}
+}
@@ -3,9 +3,15 @@ package notificationhubs
 
 import "time"
 
-// NewNotificationHub initializes and returns NotificationHub pointer
-func NewNotificationHub(connectionString, hubPath string) *NotificationHub {
-	return newNotificationHub(connectionString, hubPath)
+// NewNotificationHub initializes and returns NotificationHub pointer configured with opts
+func NewNotificationHub(connectionString, hubPath string, opts ...HubOption) *NotificationHub {
+	return newNotificationHub(connectionString, hubPath, opts...)
+}
+
+// NewNotificationHubE validates connectionString and returns a NotificationHub pointer.
+// hubPath may be empty when the connection string has an EntityPath.
+func NewNotificationHubE(connectionString, hubPath string, opts ...HubOption) (*NotificationHub, error) {
+	return newNotificationHubE(connectionString, hubPath, opts...)
 }
 
 // NewNotification initializes and returns Notification pointer
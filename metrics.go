@@ -0,0 +1,77 @@
+package notificationhubs
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+// Metrics receives measurements of hub requests.
+// The methods map onto Prometheus counters and histograms labelled by operation,
+// without tying the SDK to a metrics library.
+type Metrics interface {
+	// IncRequests counts a finished request by operation and HTTP status, 0 when no response was received
+	IncRequests(operation string, status int)
+	// ObserveLatency records how long a request took
+	ObserveLatency(operation string, latency time.Duration)
+	// IncRetries counts a request retried by the HTTP client
+	IncRetries(operation string)
+	// AddBytesSent counts the request body bytes sent
+	AddBytesSent(operation string, bytes int64)
+	// ObserveBatchSize records the number of devices of a SendDirectBatch
+	ObserveBatchSize(devices int)
+	// AddNotificationOutcomes counts delivery outcomes by platform
+	AddNotificationOutcomes(platform string, outcome NotificationOutcomeName, count int)
+}
+
+// RecordNotificationOutcomes reports the per platform outcome counts of details to m
+func RecordNotificationOutcomes(m Metrics, details *NotificationDetails) {
+	if m == nil || details == nil {
+		return
+	}
+	for platform, outcomes := range details.outcomeCounts() {
+		if outcomes == nil {
+			continue
+		}
+		for _, outcome := range outcomes.Outcomes {
+			m.AddNotificationOutcomes(platform, outcome.Name, outcome.Count)
+		}
+	}
+}
+
+// outcomeCounts returns the outcome counts of details by platform
+func (d *NotificationDetails) outcomeCounts() map[string]*NotificationOutcomes {
+	return map[string]*NotificationOutcomes{
+		string(APNSPlatform):  d.ApnsOutcomeCounts,
+		string(FCMV1Platform): d.FcmV1OutcomeCounts,
+		string(ADMPlatform):   d.AdmOutcomeCounts,
+		string(BaiduPlatform): d.BaiduOutcomeCounts,
+	}
+}
+
+// measureRequest prepares the metrics of a request and returns the function recording its outcome
+func (cfg *hubConfig) measureRequest(ctx context.Context, op operation, req *http.Request) (context.Context, func(response *http.Response)) {
+	if cfg.metrics == nil {
+		return ctx, func(*http.Response) {}
+	}
+
+	m := cfg.metrics
+	if req.ContentLength > 0 {
+		m.AddBytesSent(string(op), req.ContentLength)
+	}
+	ctx = utils.ContextWithRetryHook(ctx, func(int, time.Duration) {
+		m.IncRetries(string(op))
+	})
+
+	start := time.Now()
+	return ctx, func(response *http.Response) {
+		status := 0
+		if response != nil {
+			status = response.StatusCode
+		}
+		m.IncRequests(string(op), status)
+		m.ObserveLatency(string(op), time.Since(start))
+	}
+}
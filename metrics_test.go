@@ -0,0 +1,171 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+type mockMetrics struct {
+	mu        sync.Mutex
+	requests  map[string]int
+	latencies int
+	retries   map[string]int
+	bytesSent map[string]int64
+	batches   []int
+	outcomes  map[string]int
+}
+
+func newMockMetrics() *mockMetrics {
+	return &mockMetrics{
+		requests:  map[string]int{},
+		retries:   map[string]int{},
+		bytesSent: map[string]int64{},
+		outcomes:  map[string]int{},
+	}
+}
+
+func (m *mockMetrics) IncRequests(operation string, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[operation+":"+http.StatusText(status)]++
+}
+
+func (m *mockMetrics) ObserveLatency(operation string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencies++
+}
+
+func (m *mockMetrics) IncRetries(operation string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[operation]++
+}
+
+func (m *mockMetrics) AddBytesSent(operation string, bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytesSent[operation] += bytes
+}
+
+func (m *mockMetrics) ObserveBatchSize(devices int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches = append(m.batches, devices)
+}
+
+func (m *mockMetrics) AddNotificationOutcomes(platform string, outcome NotificationOutcomeName, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outcomes[platform+":"+string(outcome)] += count
+}
+
+func Test_MetricsSendDirectBatch(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		metrics                        = newMockMetrics()
+	)
+	nhub = nhub.With(WithMetrics(metrics))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+
+	if _, _, err := nhub.SendDirectBatch(context.Background(), notification, "a", "b", "c"); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if got := metrics.requests["SendDirectBatch:Created"]; got != 1 {
+		t.Errorf(errfmt, "requests", 1, metrics.requests)
+	}
+	if metrics.latencies != 1 {
+		t.Errorf(errfmt, "latencies", 1, metrics.latencies)
+	}
+	if metrics.bytesSent["SendDirectBatch"] <= int64(len(notification.Payload)) {
+		t.Errorf(errfmt, "bytes sent", "> payload size", metrics.bytesSent)
+	}
+	if len(metrics.batches) != 1 || metrics.batches[0] != 3 {
+		t.Errorf(errfmt, "batch sizes", []int{3}, metrics.batches)
+	}
+}
+
+func Test_MetricsCountRetries(t *testing.T) {
+	var (
+		calls  int
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		policy = utils.DefaultRetryPolicy()
+	)
+	defer server.Close()
+	policy.BaseDelay = time.Millisecond
+
+	var (
+		nhub            = NewNotificationHub("Endpoint="+server.URL+"/;SharedAccessKeyName=name;SharedAccessKey=key", hubPath)
+		notification, _ = NewNotification(Template, []byte("{}"))
+		metrics         = newMockMetrics()
+	)
+	nhub.SetHTTPClient(utils.NewHubHTTPClient(utils.WithRetryPolicy(policy)))
+	nhub = nhub.With(WithMetrics(metrics))
+
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if metrics.retries["Send"] != 1 {
+		t.Errorf(errfmt, "retries", 1, metrics.retries)
+	}
+	if metrics.requests["Send:Created"] != 1 {
+		t.Errorf(errfmt, "requests", 1, metrics.requests)
+	}
+}
+
+func TestRecordNotificationOutcomes(t *testing.T) {
+	var (
+		metrics = newMockMetrics()
+		details = &NotificationDetails{
+			ApnsOutcomeCounts: &NotificationOutcomes{Outcomes: []NotificationOutcome{
+				{Name: Success, Count: 8},
+				{Name: WrongToken, Count: 2},
+			}},
+			FcmV1OutcomeCounts: &NotificationOutcomes{Outcomes: []NotificationOutcome{
+				{Name: Success, Count: 5},
+			}},
+			AdmOutcomeCounts: &NotificationOutcomes{Outcomes: []NotificationOutcome{
+				{Name: Success, Count: 1},
+			}},
+			BaiduOutcomeCounts: &NotificationOutcomes{Outcomes: []NotificationOutcome{
+				{Name: Success, Count: 3},
+			}},
+		}
+	)
+
+	RecordNotificationOutcomes(metrics, details)
+
+	expected := map[string]int{
+		"apns:Success":    8,
+		"apns:WrongToken": 2,
+		"fcmv1:Success":   5,
+		"adm:Success":     1,
+		"baidu:Success":   3,
+	}
+	for key, want := range expected {
+		if got := metrics.outcomes[key]; got != want {
+			t.Errorf(errfmt, key, want, got)
+		}
+	}
+	if len(metrics.outcomes) != len(expected) {
+		t.Errorf(errfmt, "outcomes", expected, metrics.outcomes)
+	}
+}
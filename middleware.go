@@ -0,0 +1,22 @@
+package notificationhubs
+
+import "github.com/koreset/azure-notificationhubs-sdk-go/utils"
+
+// Middleware wraps the HTTPClient executing hub requests.
+// Middlewares see each request after it has been built and signed,
+// and the response after it has been read by the underlying client.
+// Streamed responses, as read by EachRegistration, reach them unread with no body bytes.
+type Middleware func(next utils.HTTPClient) utils.HTTPClient
+
+// chain returns the hub HTTPClient, retrying with the hub retry policy, wrapped in all registered middlewares
+func (cfg *hubConfig) chain() utils.HTTPClient {
+	client := cfg.client
+	if cfg.retryPolicy != nil {
+		client = utils.WithRetries(client, *cfg.retryPolicy)
+	}
+	client = streamingClient(client)
+	for i := len(cfg.middlewares) - 1; i >= 0; i-- {
+		client = cfg.middlewares[i](client)
+	}
+	return client
+}
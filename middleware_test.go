@@ -0,0 +1,83 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+func Test_MiddlewareChain(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		calls                          []string
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		calls = append(calls, "client")
+		if got := req.Header.Get("X-Custom"); got != "injected" {
+			t.Errorf(errfmt, "injected header", "injected", got)
+		}
+		return []byte("ok"), &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+
+	nhub = nhub.With(WithMiddleware(
+		func(next utils.HTTPClient) utils.HTTPClient {
+			return utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+				calls = append(calls, "audit")
+				if !strings.HasPrefix(req.Header.Get("Authorization"), "SharedAccessSignature ") {
+					t.Errorf(errfmt, "signed request", "SharedAccessSignature", req.Header.Get("Authorization"))
+				}
+				raw, resp, err := next.Exec(req)
+				if resp == nil || resp.StatusCode != http.StatusCreated || string(raw) != "ok" {
+					t.Errorf(errfmt, "decoded response", "201 ok", resp)
+				}
+				return raw, resp, err
+			})
+		},
+		func(next utils.HTTPClient) utils.HTTPClient {
+			return utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+				calls = append(calls, "headers")
+				req.Header.Set("X-Custom", "injected")
+				return next.Exec(req)
+			})
+		},
+	))
+
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if strings.Join(calls, ",") != "audit,headers,client" {
+		t.Errorf(errfmt, "call order", "audit,headers,client", calls)
+	}
+}
+
+func Test_MiddlewareFaultInjection(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		injected                       = errors.New("injected fault")
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		t.Error("client should not be called")
+		return nil, nil, nil
+	}
+
+	nhub = nhub.With(WithMiddleware(func(next utils.HTTPClient) utils.HTTPClient {
+		return utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+			return nil, nil, injected
+		})
+	}))
+
+	_, _, err := nhub.Send(context.Background(), notification, nil)
+
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || !errors.Is(err, injected) {
+		t.Errorf(errfmt, "error", injected, err)
+	}
+}
@@ -3,13 +3,25 @@ package notificationhubs
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
+// DefaultApnsTTL is how long APNs keeps trying to deliver a notification by default
+const DefaultApnsTTL = time.Hour
+
 type (
 	// Notification is a message that can be sent through the hub
 	Notification struct {
 		Format  NotificationFormat
 		Payload []byte
+		// ApnsTTL is how long APNs keeps trying to deliver the notification,
+		// the hub default is used when it is 0
+		ApnsTTL time.Duration
+		// Headers are platform headers sent with the notification, such as X-WNS-Type.
+		// They cannot replace the headers set by the hub.
+		Headers Headers
 	}
 
 	// IosBackgroundNotificationPayload is the payload required for a background notification
@@ -26,7 +38,40 @@ func newNotification(format NotificationFormat, payload []byte) (*Notification,
 		return nil, fmt.Errorf("unknown format '%s'", format)
 	}
 
-	return &Notification{format, payload}, nil
+	return &Notification{Format: format, Payload: payload}, nil
+}
+
+// GetContentType returns the Content-Type the notification is sent with.
+// It is the Content-Type of its format, except for raw WNS notifications
+// which are sent as application/octet-stream.
+func (n *Notification) GetContentType() string {
+	if n.Format == WindowsFormat {
+		for header, value := range n.Headers {
+			if http.CanonicalHeaderKey(header) == http.CanonicalHeaderKey(wnsTypeHeader) && value == string(WnsRaw) {
+				return "application/octet-stream"
+			}
+		}
+	}
+	return n.Format.GetContentType()
+}
+
+// headers returns the hub headers with the notification headers added.
+// Notification headers never replace hub headers or set ServiceBusNotification or Authorization headers.
+func (n *Notification) headers(hubHeaders Headers) Headers {
+	headers := make(Headers, len(n.Headers)+len(hubHeaders))
+	for header, value := range hubHeaders {
+		headers[header] = value
+	}
+	for header, value := range n.Headers {
+		header = http.CanonicalHeaderKey(header)
+		if strings.HasPrefix(header, "Servicebusnotification-") || header == "Authorization" {
+			continue
+		}
+		if _, ok := hubHeaders[header]; !ok {
+			headers[header] = value
+		}
+	}
+	return headers
 }
 
 // String returns Notification string representation
@@ -34,12 +79,21 @@ func (n *Notification) String() string {
 	return fmt.Sprintf("&{%s %s}", n.Format, string(n.Payload))
 }
 
+// isIosBackgroundNotification reports whether payload is a background notification,
+// an aps dictionary holding nothing but content-available. A visible alert that also
+// wakes the app is still sent as an alert.
 func isIosBackgroundNotification(payload []byte) bool {
 	var backgroundNotification IosBackgroundNotificationPayload
 	err := json.Unmarshal(payload, &backgroundNotification)
-	if err != nil {
+	if err != nil || backgroundNotification.Aps.ContentAvailable != 1 {
 		return false
 	}
 
-	return backgroundNotification.Aps.ContentAvailable == 1
+	var aps struct {
+		Aps map[string]json.RawMessage `json:"aps"`
+	}
+	if err = json.Unmarshal(payload, &aps); err != nil {
+		return false
+	}
+	return len(aps.Aps) == 1
 }
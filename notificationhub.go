@@ -2,31 +2,44 @@ package notificationhubs
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
 )
 
-// NotificationHub is a client for sending messages through Azure Notification Hubs
+// NotificationHub is a client for sending messages through Azure Notification Hubs.
+// It is safe for concurrent use. Configuration changes are atomic:
+// operations in flight finish with the configuration they started with.
 type NotificationHub struct {
+	// SasKeyValue is a copy of the key value of the connection string.
+	//
+	// Deprecated: changing it has no effect, rotate keys with SetSasKeys.
 	SasKeyValue string
-	SasKeyName  string
-	HubURL      *url.URL
-
-	client                  utils.HTTPClient
-	expirationTimeGenerator utils.ExpirationTimeGenerator
+	// SasKeyName is a copy of the key name of the connection string.
+	//
+	// Deprecated: changing it has no effect, rotate keys with SetSasKeys.
+	SasKeyName string
+	// HubURL is a copy of the hub URL made when the hub is created.
+	// Requests always go to the URL of the hub configuration, changing HubURL has no effect.
+	HubURL *url.URL
+
+	mu       sync.Mutex // serializes configuration changes
+	config   atomic.Pointer[hubConfig]
+	keys     keyRing
+	sasToken sasTokenCache
 }
 
 // newNotificationHub initializes and returns NotificationHub pointer
-func newNotificationHub(connectionString, hubPath string) *NotificationHub {
+func newNotificationHub(connectionString, hubPath string, opts ...HubOption) *NotificationHub {
 	var (
 		connData    = strings.Split(connectionString, ";")
 		_url        = &url.URL{}
@@ -56,77 +69,167 @@ func newNotificationHub(connectionString, hubPath string) *NotificationHub {
 	if _url.Scheme == schemeServiceBus || _url.Scheme == "" {
 		_url.Scheme = schemeDefault
 	}
+	return newHub(_url, sasKeyName, sasKeyValue, hubPath, opts...)
+}
 
+// newHub creates a hub for hubPath in the namespace at endpoint
+func newHub(_url *url.URL, sasKeyName, sasKeyValue, hubPath string, opts ...HubOption) *NotificationHub {
 	_url.Path = hubPath
 	_url.RawQuery = url.Values{apiVersionParam: {apiVersionValue}}.Encode()
-	return &NotificationHub{
+	cfg := defaultConfig(_url)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	h := &NotificationHub{
 		SasKeyName:  sasKeyName,
 		SasKeyValue: sasKeyValue,
-		HubURL:      _url,
-
-		client:                  utils.NewHubHTTPClient(),
-		expirationTimeGenerator: utils.NewExpirationTimeGenerator(),
+		HubURL:      cloneURL(cfg.hubURL),
 	}
+	h.config.Store(cfg)
+	h.keys.primary = SasKey{Name: sasKeyName, Value: sasKeyValue}
+	return h
 }
 
 // SetHTTPClient makes it possible to use a custom http client
+//
+// Deprecated: use WithHTTPClient.
 func (h *NotificationHub) SetHTTPClient(c utils.HTTPClient) {
-	h.client = c
+	h.update(WithHTTPClient(c))
 }
 
 // SetExpirationTimeGenerator makes is possible to use a custom generator
+// of the SAS token expiry. It does not affect the APNs expiration, see WithApnsTTL.
+// The cached SAS token is dropped, so the next request uses the new expiry.
+//
+// Deprecated: use WithExpirationTimeGenerator.
 func (h *NotificationHub) SetExpirationTimeGenerator(e utils.ExpirationTimeGenerator) {
-	h.expirationTimeGenerator = e
+	h.update(WithExpirationTimeGenerator(e))
+	h.sasToken.invalidate()
 }
 
-// generateSasToken generates and returns
-// azure notification hub shared access signature token
-func (h *NotificationHub) generateSasToken() string {
+// generateSasToken returns the SAS token signed with key authorizing hub requests.
+// The token is cached and only signed again when it is close to expiry.
+func (h *NotificationHub) generateSasToken(cfg *hubConfig, key SasKey) string {
 	uri := &url.URL{
-		Host:   h.HubURL.Host,
-		Scheme: h.HubURL.Scheme,
+		Host:   cfg.hubURL.Host,
+		Scheme: cfg.hubURL.Scheme,
 	}
 	targetURI := strings.ToLower(uri.String())
 
-	expires := h.expirationTimeGenerator.GenerateTimestamp()
-	toSign := fmt.Sprintf("%s\n%d", url.QueryEscape(targetURI), expires)
-
-	mac := hmac.New(sha256.New, []byte(h.SasKeyValue))
-	mac.Write([]byte(toSign))
-	macb := mac.Sum(nil)
-
-	signature := base64.StdEncoding.EncodeToString(macb)
+	return h.sasToken.get(key.Name, key.Value, targetURI, cfg.tokenRenewalSkew, func() (string, int64) {
+		expires := cfg.expirationTimeGenerator.GenerateTimestamp()
+		return signSasToken(targetURI, key.Name, key.Value, expires), expires
+	})
+}
 
+// signSasToken signs a SAS token for targetURI valid until expires
+func signSasToken(targetURI, keyName, keyValue string, expires int64) string {
 	tokenParams := url.Values{
 		"sr":  {targetURI},
-		"sig": {signature},
+		"sig": {sasSignature(targetURI, keyValue, expires)},
 		"se":  {fmt.Sprintf("%d", expires)},
-		"skn": {h.SasKeyName},
+		"skn": {keyName},
 	}
 
-	return fmt.Sprintf("SharedAccessSignature %s", tokenParams.Encode())
+	return fmt.Sprintf("%s%s", sasTokenPrefix, tokenParams.Encode())
 }
 
-// exec request using method to url
-func (h *NotificationHub) exec(ctx context.Context, method string, url *url.URL, headers Headers, buf io.Reader) ([]byte, *http.Response, error) {
-	headers["Authorization"] = h.generateSasToken()
-	req, err := http.NewRequest(method, url.String(), buf)
+// exec request using method to url.
+// Any returned error is a *NotificationHubError describing op
+func (h *NotificationHub) exec(ctx context.Context, op operation, method string, url *url.URL, headers Headers, buf io.Reader) ([]byte, *http.Response, error) {
+	ctx, cfg := h.snapshot(ctx)
+	version, err := cfg.apiVersion(ctx, op)
 	if err != nil {
 		return nil, nil, err
 	}
-	req = req.WithContext(ctx)
+	if query := url.Query(); query.Get(apiVersionParam) != version {
+		query.Set(apiVersionParam, version)
+		url.RawQuery = query.Encode()
+	}
+
+	if cfg.rateLimiter != nil {
+		if err := cfg.rateLimiter.Wait(ctx, op.class()); err != nil {
+			return nil, nil, newOperationError(op, ErrorCodeRateLimited, err)
+		}
+	}
+
+	auth, err := h.authorization(ctx, cfg)
+	if err != nil {
+		return nil, nil, newOperationError(op, ErrorCodeAuthenticationFailed, err)
+	}
+	headers["Authorization"] = auth.header
+	req, err := http.NewRequest(method, url.String(), buf)
+	if err != nil {
+		return nil, nil, newOperationError(op, ErrorCodeInvalidRequest, err)
+	}
+	if body, ok := buf.(*pipeBody); ok {
+		req.ContentLength = body.size
+		req.GetBody = body.reopen
+	}
 	for header, val := range headers {
 		req.Header.Set(header, val)
 	}
-	return h.client.Exec(req)
+	if cfg.userAgent != "" {
+		req.Header.Set("User-Agent", cfg.userAgent)
+	}
+
+	raw, response, err := cfg.do(ctx, op, req)
+	if err != nil && auth.shared && isAuthFailure(response) && h.fallback(ctx, auth.slot) {
+		// the key may have been rotated, retry once with the alternate key
+		if retry, rerr := h.resign(ctx, cfg, req); rerr == nil {
+			raw, response, err = cfg.do(ctx, op, retry)
+		}
+	}
+	if err != nil {
+		return raw, response, newOperationError(op, ErrorCodeRequestFailed, err)
+	}
+	return raw, response, nil
+}
+
+// do sends a built request through the middleware chain and reports it
+func (cfg *hubConfig) do(ctx context.Context, op operation, req *http.Request) ([]byte, *http.Response, error) {
+	cfg.traceRequest(ctx, req)
+	ctx, measured := cfg.measureRequest(ctx, op, req)
+	req = req.WithContext(ctx)
+
+	start := time.Now()
+	raw, response, err := cfg.chain().Exec(req)
+	measured(response)
+	traceResponse(ctx, response)
+	cfg.logRequest(ctx, op, req, raw, response, err, time.Since(start))
+	if cfg.rateLimiter != nil {
+		cfg.rateLimiter.observe(op.class(), response)
+	}
+	return raw, response, err
+}
+
+// resign returns a copy of req with a fresh body, signed with the active key
+func (h *NotificationHub) resign(ctx context.Context, cfg *hubConfig, req *http.Request) (*http.Request, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed")
+	}
+	auth, err := h.authorization(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", auth.header)
+	return retry, nil
 }
 
 // generate an URL for path
 func (h *NotificationHub) generateAPIURL(endpoint string) *url.URL {
+	hubURL := h.config.Load().hubURL
 	return &url.URL{
-		Host:     h.HubURL.Host,
-		Scheme:   h.HubURL.Scheme,
-		Path:     path.Join(h.HubURL.Path, endpoint),
-		RawQuery: h.HubURL.RawQuery,
+		Host:     hubURL.Host,
+		Scheme:   hubURL.Scheme,
+		Path:     path.Join(hubURL.Path, endpoint),
+		RawQuery: hubURL.RawQuery,
 	}
 }
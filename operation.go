@@ -0,0 +1,46 @@
+package notificationhubs
+
+// operation identifies a public hub API call
+type operation string
+
+const (
+	opSend                 operation = "Send"
+	opSendDirect           operation = "SendDirect"
+	opSendDirectBatch      operation = "SendDirectBatch"
+	opSchedule             operation = "Schedule"
+	opRegistration         operation = "Registration"
+	opRegistrations        operation = "Registrations"
+	opEachRegistration     operation = "EachRegistration"
+	opRegister             operation = "Register"
+	opRegisterWithTemplate operation = "RegisterWithTemplate"
+	opUnregister           operation = "Unregister"
+	opInstallation         operation = "Installation"
+	opInstall              operation = "Install"
+	opUpdate               operation = "Update"
+	opUninstall            operation = "Uninstall"
+	opNotificationDetails  operation = "NotificationDetails"
+)
+
+// notFoundCode returns the error code reported when the hub answers the operation with 404
+func (o operation) notFoundCode() ErrorCode {
+	switch o {
+	case opRegistration, opRegister, opRegisterWithTemplate, opUnregister:
+		return ErrorCodeRegistrationNotFound
+	case opInstallation, opInstall, opUpdate, opUninstall:
+		return ErrorCodeInstallationNotFound
+	case opNotificationDetails:
+		return ErrorCodeNotificationNotFound
+	}
+	return ErrorCodeNotFound
+}
+
+// class returns the rate limiting class of the operation
+func (o operation) class() OperationClass {
+	switch o {
+	case opSend, opSendDirect, opSendDirectBatch, opSchedule:
+		return SendOperations
+	case opNotificationDetails:
+		return TelemetryOperations
+	}
+	return RegistrationOperations
+}
@@ -0,0 +1,148 @@
+package notificationhubs
+
+import (
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+// HubOption configures a NotificationHub when it is created
+type HubOption func(*hubConfig)
+
+// WithHTTPClient makes the hub send its requests through client
+func WithHTTPClient(client utils.HTTPClient) HubOption {
+	return func(c *hubConfig) {
+		c.client = client
+	}
+}
+
+// WithRetryPolicy makes the hub retry failed requests with policy. The policy is applied on top of
+// the HTTP client of the hub, whether it is set before or after, and replaces the policy of a utils.HubHTTPClient.
+func WithRetryPolicy(policy utils.RetryPolicy) HubOption {
+	return func(c *hubConfig) {
+		c.retryPolicy = &policy
+	}
+}
+
+// WithLogger makes the hub log every request to l.
+// Requests are logged at debug level and failures at warn level.
+// Authorization headers, signatures, keys and device handles are always redacted.
+func WithLogger(l *slog.Logger) HubOption {
+	return func(c *hubConfig) {
+		c.logger = l
+	}
+}
+
+// WithAPIVersion makes the hub call the REST API version v instead of DefaultAPIVersion.
+// Operations the version does not support fail with ErrorCodeUnsupportedAPIVersion.
+func WithAPIVersion(v string) HubOption {
+	return func(c *hubConfig) {
+		c.hubAPIVersion = v
+		c.hubURL = cloneURL(c.hubURL)
+		c.hubURL.RawQuery = url.Values{apiVersionParam: {v}}.Encode()
+	}
+}
+
+// WithOperationAPIVersion makes the operations of operationType, such as OperationTypeTelemetry,
+// call the REST API version v instead of the hub version
+func WithOperationAPIVersion(operationType, v string) HubOption {
+	return func(c *hubConfig) {
+		// never modify a map shared with an earlier config
+		versions := make(map[string]string, len(c.operationAPIVersions)+1)
+		for opType, version := range c.operationAPIVersions {
+			versions[opType] = version
+		}
+		versions[operationType] = v
+		c.operationAPIVersions = versions
+	}
+}
+
+// WithTokenLifetime makes the hub sign SAS tokens valid for lifetime, one hour by default
+func WithTokenLifetime(lifetime time.Duration) HubOption {
+	return func(c *hubConfig) {
+		c.expirationTimeGenerator = utils.NewExpirationTimeGeneratorWithLifetime(lifetime)
+	}
+}
+
+// WithExpirationTimeGenerator makes the hub use e to generate the expiry of SAS tokens
+func WithExpirationTimeGenerator(e utils.ExpirationTimeGenerator) HubOption {
+	return func(c *hubConfig) {
+		c.expirationTimeGenerator = e
+	}
+}
+
+// WithApnsTTL sets the default time APNs keeps trying to deliver a notification,
+// one hour by default. Notification.ApnsTTL overrides it per notification.
+func WithApnsTTL(ttl time.Duration) HubOption {
+	return func(c *hubConfig) {
+		c.apnsTTL = ttl
+	}
+}
+
+// WithUserAgent sets the User-Agent header of hub requests
+func WithUserAgent(userAgent string) HubOption {
+	return func(c *hubConfig) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRateLimiter makes the hub wait for l before every request.
+// Give each hub its own limiter to apply different limits per hub.
+func WithRateLimiter(l *RateLimiter) HubOption {
+	return func(c *hubConfig) {
+		c.rateLimiter = l
+	}
+}
+
+// WithMiddleware appends middlewares to the chain around the hub HTTP client.
+// The first registered middleware is the outermost one.
+func WithMiddleware(middlewares ...Middleware) HubOption {
+	return func(c *hubConfig) {
+		// never append to a slice shared with an earlier config
+		c.middlewares = append(c.middlewares[:len(c.middlewares):len(c.middlewares)], middlewares...)
+	}
+}
+
+// WithTracer makes the hub open a span with t for every public operation
+func WithTracer(t Tracer) HubOption {
+	return func(c *hubConfig) {
+		c.tracer = t
+	}
+}
+
+// WithMetrics makes the hub report its requests to m
+func WithMetrics(m Metrics) HubOption {
+	return func(c *hubConfig) {
+		c.metrics = m
+	}
+}
+
+// WithTokenCredential makes the hub authorize its requests with bearer tokens from credential
+// instead of SAS tokens. Tokens are cached and renewed ahead of their expiry,
+// see WithTokenRenewalSkew. A nil credential restores SAS authorization.
+func WithTokenCredential(credential TokenCredential) HubOption {
+	return func(c *hubConfig) {
+		c.bearerToken = nil
+		if credential != nil {
+			c.bearerToken = &bearerTokenCache{credential: credential}
+		}
+	}
+}
+
+// WithLogBodies makes the hub logger include request and response bodies,
+// truncated to maxBytes. Bodies are not logged when maxBytes is 0.
+func WithLogBodies(maxBytes int) HubOption {
+	return func(c *hubConfig) {
+		c.logBodyLimit = maxBytes
+	}
+}
+
+// WithTokenRenewalSkew sets how long before their expiry tokens are renewed.
+// It defaults to DefaultTokenRenewalSkew.
+func WithTokenRenewalSkew(skew time.Duration) HubOption {
+	return func(c *hubConfig) {
+		c.tokenRenewalSkew = skew
+	}
+}
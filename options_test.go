@@ -0,0 +1,130 @@
+package notificationhubs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+func Test_NewNotificationHubWithOptions(t *testing.T) {
+	var (
+		mockClient = &mockHubHTTPClient{}
+		logs       = &bytes.Buffer{}
+		limiter    = NewRateLimiter(map[OperationClass]RateLimit{SendOperations: {Rate: 0.001, Burst: 1}})
+		nhub       = NewNotificationHub(connectionString, hubPath,
+			WithHTTPClient(mockClient),
+			WithUserAgent("my-sender/1.0"),
+			WithAPIVersion(LegacyAPIVersion),
+			WithTokenLifetime(10*time.Minute),
+			WithApnsTTL(2*time.Hour),
+			WithLogger(slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+			WithRateLimiter(limiter),
+		)
+		notification, _ = NewNotification(AppleFormat, []byte(`{"aps":{}}`))
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		if got := req.Header.Get("User-Agent"); got != "my-sender/1.0" {
+			t.Errorf(errfmt, "User-Agent", "my-sender/1.0", got)
+		}
+		if got := req.URL.Query().Get(apiVersionParam); got != LegacyAPIVersion {
+			t.Errorf(errfmt, "api-version", LegacyAPIVersion, got)
+		}
+		token, _ := url.ParseQuery(strings.TrimPrefix(req.Header.Get("Authorization"), "SharedAccessSignature "))
+		expires, _ := strconv.ParseInt(token.Get("se"), 10, 64)
+		if want := time.Now().Add(10 * time.Minute).Unix(); expires < want-5 || expires > want {
+			t.Errorf(errfmt, "token expiry", want, expires)
+		}
+		apnsExpiration, _ := strconv.ParseInt(req.Header.Get("X-Apns-Expiration"), 10, 64)
+		if want := time.Now().Add(2 * time.Hour).Unix(); apnsExpiration < want-5 || apnsExpiration > want {
+			t.Errorf(errfmt, "APNs expiration", want, apnsExpiration)
+		}
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if !strings.Contains(logs.String(), "operation=Send") {
+		t.Errorf(errfmt, "log", "operation=Send", logs.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := nhub.Send(ctx, notification, nil)
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeRateLimited {
+		t.Errorf(errfmt, "rate limited", ErrorCodeRateLimited, err)
+	}
+}
+
+func Test_NewNotificationHubWithOptionsConcurrentUse(t *testing.T) {
+	var (
+		mockClient = &mockHubHTTPClient{execFunc: func(req *http.Request) ([]byte, *http.Response, error) {
+			return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+		}}
+		nhub, err = NewNotificationHubE(connectionString, hubPath,
+			WithHTTPClient(mockClient),
+			WithMetrics(newMockMetrics()),
+			WithUserAgent("my-sender/1.0"),
+		)
+		notification, _ = NewNotification(Template, []byte("{}"))
+		wg              sync.WaitGroup
+	)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+				t.Errorf(errfmt, "error", nil, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_WithRetryPolicyKeepsTheHTTPClient(t *testing.T) {
+	policy := utils.DefaultRetryPolicy()
+	policy.BaseDelay, policy.Jitter = time.Millisecond, 0
+	notification, _ := NewNotification(AppleFormat, []byte(`{"aps":{}}`))
+
+	for name, order := range map[string]func(HubOption, HubOption) []HubOption{
+		"client first": func(client, retry HubOption) []HubOption { return []HubOption{client, retry} },
+		"policy first": func(client, retry HubOption) []HubOption { return []HubOption{retry, client} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				calls      int
+				mockClient = &mockHubHTTPClient{execFunc: func(req *http.Request) ([]byte, *http.Response, error) {
+					if calls++; calls < 3 {
+						return statusErrorResponse(http.StatusServiceUnavailable, nil, "")
+					}
+					return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+				}}
+				nhub = NewNotificationHub(connectionString, hubPath, order(WithHTTPClient(mockClient), WithRetryPolicy(policy))...)
+			)
+
+			if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+				t.Fatalf(errfmt, "error", nil, err)
+			}
+			if calls != 3 {
+				t.Errorf(errfmt, "calls of the given client", 3, calls)
+			}
+		})
+	}
+}
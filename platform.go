@@ -1,7 +1,9 @@
 package notificationhubs
 
 // GetContentType returns Content-Type
-// associated with NotificationFormat
+// associated with NotificationFormat.
+// The format cannot tell raw WNS notifications, which are binary,
+// use Notification.GetContentType for the Content-Type a notification is sent with.
 func (f NotificationFormat) GetContentType() string {
 	switch f {
 	case Template,
@@ -42,3 +44,13 @@ func (f TargetPlatform) IsValid() bool {
 		f == WindowsPlatform ||
 		f == WindowsTemplatePlatform
 }
+
+// IsValid identifies whether installation platform is valid
+func (p InstallationPlatform) IsValid() bool {
+	return p == APNSPlatform ||
+		p == WNSPlatform ||
+		p == MPNSPlatform ||
+		p == ADMPlatform ||
+		p == BAIDUPlatform ||
+		p == FCMV1Platform
+}
@@ -0,0 +1,179 @@
+package notificationhubs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+// OperationClass groups hub operations sharing a rate limit
+type OperationClass string
+
+const (
+	// SendOperations are Send, Schedule, SendDirect and SendDirectBatch
+	SendOperations OperationClass = "send"
+	// RegistrationOperations manage registrations and installations
+	RegistrationOperations OperationClass = "registration"
+	// TelemetryOperations read notification telemetry
+	TelemetryOperations OperationClass = "telemetry"
+)
+
+// RateLimit is the sustained rate and burst size of a token bucket
+type RateLimit struct {
+	// Rate is the number of requests allowed per second
+	Rate float64
+	// Burst is the number of requests that may be sent at once
+	Burst int
+}
+
+// RateLimiter limits outgoing hub requests per operation class.
+// It tightens automatically when the hub answers with 429 Too Many Requests.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[OperationClass]*tokenBucket
+}
+
+// tokenBucket is the state of a single operation class
+type tokenBucket struct {
+	limit  RateLimit
+	rate   float64
+	tokens float64
+	// last is the time tokens were last refilled,
+	// in the future while the bucket is paused after throttling
+	last time.Time
+}
+
+// NewRateLimiter creates a limiter for the given classes.
+// Classes without a limit are not limited.
+func NewRateLimiter(limits map[OperationClass]RateLimit) *RateLimiter {
+	var (
+		now = time.Now()
+		l   = &RateLimiter{buckets: make(map[OperationClass]*tokenBucket, len(limits))}
+	)
+	for class, limit := range limits {
+		if limit.Rate <= 0 {
+			continue
+		}
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		l.buckets[class] = &tokenBucket{
+			limit:  limit,
+			rate:   limit.Rate,
+			tokens: float64(limit.Burst),
+			last:   now,
+		}
+	}
+	return l
+}
+
+// Wait blocks until a request of the given class may be sent.
+// It fails fast with ErrorCodeRateLimited when the wait would outlast the context deadline.
+func (l *RateLimiter) Wait(ctx context.Context, class OperationClass) error {
+	l.mu.Lock()
+	b, ok := l.buckets[class]
+	if !ok {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	delay := b.reserve(now)
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		b.tokens++
+		l.mu.Unlock()
+		err := NewError(ErrorCodeRateLimited, fmt.Sprintf("rate limit for %s operations exceeded", class))
+		err.RetryAfter = delay
+		return err
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe adjusts the limit of class to the response of the hub
+func (l *RateLimiter) observe(class OperationClass, response *http.Response) {
+	if response == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[class]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		retryAfter, _ := utils.ParseRetryAfter(response.Header, now)
+		b.throttle(now, retryAfter)
+	case response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices:
+		b.relax()
+	}
+}
+
+// refill adds the tokens earned since the last refill
+func (b *tokenBucket) refill(now time.Time) {
+	if !now.After(b.last) {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if burst := float64(b.limit.Burst); b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens--
+
+	delay := b.last.Sub(now)
+	if b.tokens < 0 {
+		delay += time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	return delay
+}
+
+// throttle drains the bucket, pauses it for retryAfter and halves its rate
+func (b *tokenBucket) throttle(now time.Time, retryAfter time.Duration) {
+	b.refill(now)
+	if b.tokens > 0 {
+		b.tokens = 0
+	}
+	if resume := now.Add(retryAfter); resume.After(b.last) {
+		b.last = resume
+	}
+	if minRate := b.limit.Rate / 16; b.rate/2 > minRate {
+		b.rate /= 2
+	} else {
+		b.rate = minRate
+	}
+}
+
+// relax moves the rate of a throttled bucket back towards its configured limit
+func (b *tokenBucket) relax() {
+	b.rate += b.limit.Rate / 10
+	if b.rate > b.limit.Rate {
+		b.rate = b.limit.Rate
+	}
+}
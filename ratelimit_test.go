@@ -0,0 +1,103 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func Test_RateLimiterWaits(t *testing.T) {
+	var (
+		limiter = NewRateLimiter(map[OperationClass]RateLimit{
+			SendOperations: {Rate: 50, Burst: 2},
+		})
+		ctx   = context.Background()
+		start = time.Now()
+	)
+
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(ctx, SendOperations); err != nil {
+			t.Fatalf(errfmt, "error", nil, err)
+		}
+	}
+	// two requests fit in the burst, the next two wait 20ms each
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf(errfmt, "elapsed", ">= 30ms", elapsed)
+	}
+
+	start = time.Now()
+	if err := limiter.Wait(ctx, TelemetryOperations); err != nil || time.Since(start) > 10*time.Millisecond {
+		t.Errorf(errfmt, "unlimited class", "no wait", time.Since(start))
+	}
+}
+
+func Test_RateLimiterFailsFastOnDeadline(t *testing.T) {
+	var limiter = NewRateLimiter(map[OperationClass]RateLimit{
+		RegistrationOperations: {Rate: 1, Burst: 1},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx, RegistrationOperations); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	start := time.Now()
+	err := limiter.Wait(ctx, RegistrationOperations)
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeRateLimited {
+		t.Fatalf(errfmt, "error", ErrorCodeRateLimited, err)
+	}
+	if hubErr.RetryAfter <= 0 {
+		t.Errorf(errfmt, "retry after", "> 0", hubErr.RetryAfter)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf(errfmt, "elapsed", "fail fast", elapsed)
+	}
+}
+
+func Test_RateLimiterTightensOnThrottling(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		limiter                        = NewRateLimiter(map[OperationClass]RateLimit{
+			SendOperations: {Rate: 1000, Burst: 10},
+		})
+		calls int
+	)
+	nhub = nhub.With(WithRateLimiter(limiter))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		calls++
+		header := http.Header{}
+		header.Set("Retry-After", "2")
+		return statusErrorResponse(http.StatusTooManyRequests, header, "")
+	}
+
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err == nil {
+		t.Fatalf(errfmt, "error", "rate limited", nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, _, err := nhub.Send(ctx, notification, nil)
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeRateLimited {
+		t.Fatalf(errfmt, "error", ErrorCodeRateLimited, err)
+	}
+	if hubErr.Operation != "Send" {
+		t.Errorf(errfmt, "operation", "Send", hubErr.Operation)
+	}
+	if calls != 1 {
+		t.Errorf(errfmt, "calls", 1, calls)
+	}
+
+	if err := limiter.Wait(ctx, RegistrationOperations); err != nil {
+		t.Errorf(errfmt, "other class", nil, err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/xml"
 	"errors"
+	"io"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,11 +16,11 @@ import (
 func newRegistration(deviceID string, expirationTime *time.Time, notificationFormat NotificationFormat,
 	registrationID string, tags string) *Registration {
 	return &Registration{
-		deviceID,
-		expirationTime,
-		notificationFormat,
-		registrationID,
-		tags,
+		DeviceID:           deviceID,
+		ExpirationTime:     expirationTime,
+		NotificationFormat: notificationFormat,
+		RegistrationID:     registrationID,
+		Tags:               tags,
 	}
 }
 
@@ -26,12 +28,12 @@ func newRegistration(deviceID string, expirationTime *time.Time, notificationFor
 func newTemplateRegistration(deviceID string, expirationTime *time.Time, registrationID string, tags string,
 	platform TargetPlatform, template string) *TemplateRegistration {
 	return &TemplateRegistration{
-		deviceID,
-		expirationTime,
-		registrationID,
-		tags,
-		platform,
-		template,
+		DeviceID:       deviceID,
+		ExpirationTime: expirationTime,
+		RegistrationID: registrationID,
+		Tags:           tags,
+		Platform:       platform,
+		Template:       template,
 	}
 }
 
@@ -81,6 +83,34 @@ func (r *RegistrationContent) normalize() {
 		r.RegisteredDevice.FcmV1RegistrationID = nil
 		r.FcmV1RegistrationDescription = nil
 		r.FcmV1TemplateRegistrationDescription = nil
+	} else if r.AdmRegistrationDescription != nil || r.AdmTemplateRegistrationDescription != nil {
+		if r.AdmTemplateRegistrationDescription != nil {
+			r.Format = Template
+			r.Target = AdmTemplatePlatform
+			r.RegisteredDevice = r.AdmTemplateRegistrationDescription
+		} else {
+			r.Format = KindleFormat
+			r.Target = AdmPlatform
+			r.RegisteredDevice = r.AdmRegistrationDescription
+		}
+		r.RegisteredDevice.DeviceID = *r.RegisteredDevice.AdmRegistrationID
+		r.RegisteredDevice.AdmRegistrationID = nil
+		r.AdmRegistrationDescription = nil
+		r.AdmTemplateRegistrationDescription = nil
+	} else if r.BaiduRegistrationDescription != nil || r.BaiduTemplateRegistrationDescription != nil {
+		if r.BaiduTemplateRegistrationDescription != nil {
+			r.Format = Template
+			r.Target = BaiduTemplatePlatform
+			r.RegisteredDevice = r.BaiduTemplateRegistrationDescription
+		} else {
+			r.Format = BaiduFormat
+			r.Target = BaiduPlatform
+			r.RegisteredDevice = r.BaiduRegistrationDescription
+		}
+		r.RegisteredDevice.DeviceID = *r.RegisteredDevice.BaiduChannelID
+		r.RegisteredDevice.BaiduChannelID = nil
+		r.BaiduRegistrationDescription = nil
+		r.BaiduTemplateRegistrationDescription = nil
 	}
 	if r.RegisteredDevice != nil {
 		expirationTime, err := time.Parse("2006-01-02T15:04:05.000Z", *r.RegisteredDevice.ExpirationTimeString)
@@ -98,14 +128,18 @@ func (r *RegistrationContent) normalize() {
 
 // Registration reads one specific registration
 func (h *NotificationHub) Registration(ctx context.Context, registrationID string) (raw []byte, registrationResult *RegistrationResult, err error) {
+	ctx, span := h.startSpan(ctx, opRegistration)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		regURL = h.generateAPIURL(path.Join("registrations", registrationID))
 	)
-	raw, _, err = h.exec(ctx, getMethod, regURL, Headers{}, nil)
+	raw, _, err = h.exec(ctx, opRegistration, getMethod, regURL, Headers{}, nil)
 	if err != nil {
 		return
 	}
 	if err = xml.Unmarshal(raw, &registrationResult); err != nil {
+		err = newOperationError(opRegistration, ErrorCodeInvalidResponse, err)
 		return
 	}
 	registrationResult.RegistrationContent.normalize()
@@ -114,19 +148,93 @@ func (h *NotificationHub) Registration(ctx context.Context, registrationID strin
 
 // Registrations reads all registrations
 func (h *NotificationHub) Registrations(ctx context.Context) (raw []byte, registrations *Registrations, err error) {
-	raw, _, err = h.exec(ctx, getMethod, h.generateAPIURL("registrations"), Headers{}, nil)
+	ctx, span := h.startSpan(ctx, opRegistrations)
+	defer func() { endSpan(span, err) }()
+
+	raw, _, err = h.exec(ctx, opRegistrations, getMethod, h.generateAPIURL("registrations"), Headers{}, nil)
 	if err != nil {
 		return
 	}
 	if err = xml.Unmarshal(raw, &registrations); err != nil {
+		err = newOperationError(opRegistrations, ErrorCodeInvalidResponse, err)
 		return
 	}
 	registrations.normalize()
 	return
 }
 
+// EachRegistration reads all registrations page by page and calls fn for each of them.
+// Pages of pageSize registrations are decoded while they are streamed from the hub,
+// so memory use does not grow with the number of registrations.
+// Iteration stops at the first error returned by fn, which is returned as is.
+func (h *NotificationHub) EachRegistration(ctx context.Context, pageSize int, fn func(*RegistrationResult) error) (err error) {
+	ctx, span := h.startSpan(ctx, opEachRegistration)
+	defer func() { endSpan(span, err) }()
+
+	continuationToken := ""
+	for {
+		regURL := h.generateAPIURL("registrations")
+		query := regURL.Query()
+		if pageSize > 0 {
+			query.Set("$top", strconv.Itoa(pageSize))
+		}
+		if continuationToken != "" {
+			query.Set("ContinuationToken", continuationToken)
+		}
+		regURL.RawQuery = query.Encode()
+
+		body, response, serr := h.stream(ctx, opEachRegistration, getMethod, regURL, Headers{})
+		if serr != nil {
+			return serr
+		}
+		err = decodeRegistrationFeed(body, fn)
+		_ = body.Close()
+		if err != nil {
+			return err
+		}
+
+		continuationToken = ""
+		if response != nil {
+			continuationToken = response.Header.Get(continuationTokenHeader)
+		}
+		if continuationToken == "" {
+			return nil
+		}
+	}
+}
+
+// decodeRegistrationFeed decodes the entries of a registrations feed one at a time
+func decodeRegistrationFeed(r io.Reader, fn func(*RegistrationResult) error) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return newOperationError(opEachRegistration, ErrorCodeInvalidResponse, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "entry" {
+			continue
+		}
+
+		entry := &RegistrationResult{}
+		if err = decoder.DecodeElement(entry, &start); err != nil {
+			return newOperationError(opEachRegistration, ErrorCodeInvalidResponse, err)
+		}
+		entry.normalize()
+		if err = fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
 // Register sends a device registration to the Azure hub
 func (h *NotificationHub) Register(ctx context.Context, r Registration) (raw []byte, registrationResult *RegistrationResult, err error) {
+	ctx, span := h.startSpan(ctx, opRegister)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		regURL  = h.generateAPIURL("registrations")
 		method  = postMethod
@@ -141,8 +249,16 @@ func (h *NotificationHub) Register(ctx context.Context, r Registration) (raw []b
 		payload = strings.Replace(appleRegXMLString, "{{DeviceID}}", r.DeviceID, 1)
 	case FcmV1Format:
 		payload = strings.Replace(fcmV1RegXMLString, "{{DeviceID}}", r.DeviceID, 1)
+	case KindleFormat:
+		payload = strings.Replace(admRegXMLString, "{{DeviceID}}", r.DeviceID, 1)
+	case BaiduFormat:
+		if r.BaiduUserID == "" {
+			return nil, nil, newOperationError(opRegister, ErrorCodeInvalidRegistration, errors.New("Baidu registrations require a BaiduUserID"))
+		}
+		payload = strings.Replace(baiduRegXMLString, "{{DeviceID}}", r.DeviceID, 1)
+		payload = strings.Replace(payload, "{{BaiduUserID}}", r.BaiduUserID, 1)
 	default:
-		return nil, nil, errors.New("Notification format not implemented")
+		return nil, nil, newOperationError(opRegister, ErrorCodeInvalidRegistration, errors.New("Notification format not implemented"))
 	}
 	payload = strings.Replace(payload, "{{Tags}}", r.Tags, 1)
 
@@ -151,10 +267,11 @@ func (h *NotificationHub) Register(ctx context.Context, r Registration) (raw []b
 		regURL.Path = path.Join(regURL.Path, r.RegistrationID)
 	}
 
-	raw, _, err = h.exec(ctx, method, regURL, headers, bytes.NewBufferString(payload))
+	raw, _, err = h.exec(withAPIFeature(ctx, r.NotificationFormat.apiFeature()), opRegister, method, regURL, headers, bytes.NewBufferString(payload))
 
 	if err == nil {
 		if err = xml.Unmarshal(raw, &registrationResult); err != nil {
+			err = newOperationError(opRegister, ErrorCodeInvalidResponse, err)
 			return
 		}
 	}
@@ -166,6 +283,9 @@ func (h *NotificationHub) Register(ctx context.Context, r Registration) (raw []b
 
 // RegisterWithTemplate sends a device registration with template to the Azure hub
 func (h *NotificationHub) RegisterWithTemplate(ctx context.Context, r TemplateRegistration) (raw []byte, registrationResult *RegistrationResult, err error) {
+	ctx, span := h.startSpan(ctx, opRegisterWithTemplate)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		regURL  = h.generateAPIURL("registrations")
 		method  = postMethod
@@ -180,8 +300,16 @@ func (h *NotificationHub) RegisterWithTemplate(ctx context.Context, r TemplateRe
 		payload = strings.Replace(appleTemplateRegXMLString, "{{DeviceID}}", r.DeviceID, 1)
 	case FcmV1Platform:
 		payload = strings.Replace(fcmV1TemplateRegXMLString, "{{DeviceID}}", r.DeviceID, 1)
+	case AdmPlatform:
+		payload = strings.Replace(admTemplateRegXMLString, "{{DeviceID}}", r.DeviceID, 1)
+	case BaiduPlatform:
+		if r.BaiduUserID == "" {
+			return nil, nil, newOperationError(opRegisterWithTemplate, ErrorCodeInvalidRegistration, errors.New("Baidu registrations require a BaiduUserID"))
+		}
+		payload = strings.Replace(baiduTemplateRegXMLString, "{{DeviceID}}", r.DeviceID, 1)
+		payload = strings.Replace(payload, "{{BaiduUserID}}", r.BaiduUserID, 1)
 	default:
-		return nil, nil, errors.New("Notification format not implemented")
+		return nil, nil, newOperationError(opRegisterWithTemplate, ErrorCodeInvalidRegistration, errors.New("Notification format not implemented"))
 	}
 	payload = strings.Replace(payload, "{{Tags}}", r.Tags, 1)
 	payload = strings.Replace(payload, "{{Template}}", r.Template, 1)
@@ -191,10 +319,11 @@ func (h *NotificationHub) RegisterWithTemplate(ctx context.Context, r TemplateRe
 		regURL.Path = path.Join(regURL.Path, r.RegistrationID)
 	}
 
-	raw, _, err = h.exec(ctx, method, regURL, headers, bytes.NewBufferString(payload))
+	raw, _, err = h.exec(withAPIFeature(ctx, r.Platform.apiFeature()), opRegisterWithTemplate, method, regURL, headers, bytes.NewBufferString(payload))
 
 	if err == nil {
 		if err = xml.Unmarshal(raw, &registrationResult); err != nil {
+			err = newOperationError(opRegisterWithTemplate, ErrorCodeInvalidResponse, err)
 			return
 		}
 	}
@@ -206,6 +335,9 @@ func (h *NotificationHub) RegisterWithTemplate(ctx context.Context, r TemplateRe
 
 // Unregister sends a device registration delete to the Azure hub
 func (h *NotificationHub) Unregister(ctx context.Context, registration RegisteredDevice) (err error) {
+	ctx, span := h.startSpan(ctx, opUnregister)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		regURL  = h.generateAPIURL(path.Join("registrations", registration.RegistrationID))
 		headers = map[string]string{
@@ -214,6 +346,6 @@ func (h *NotificationHub) Unregister(ctx context.Context, registration Registere
 		}
 	)
 
-	_, _, err = h.exec(ctx, deleteMethod, regURL, headers, nil)
+	_, _, err = h.exec(ctx, opUnregister, deleteMethod, regURL, headers, nil)
 	return
 }
@@ -104,8 +104,8 @@ func Test_RegisterFcmV1(t *testing.T) {
 			t.Errorf(errfmt, "method", postMethod, gotMethod)
 		}
 		gotURL := req.URL.String()
-		if gotURL != registrationsURL {
-			t.Errorf(errfmt, "URL", registrationsURL, gotURL)
+		if gotURL != fcmV1RegistrationsURL {
+			t.Errorf(errfmt, "URL", fcmV1RegistrationsURL, gotURL)
 		}
 		data, e := ioutil.ReadFile("./fixtures/fcmv1RegistrationResult.xml")
 		if e != nil {
@@ -361,3 +361,78 @@ func Test_RegisterWebClientError(t *testing.T) {
 		t.Errorf(errfmt, "error", "fail", nil)
 	}
 }
+
+func Test_EachRegistration(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		calls            int
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		calls++
+		query := req.URL.Query()
+		if got := query.Get("$top"); got != "4" {
+			t.Errorf(errfmt, "$top", "4", got)
+		}
+		header := http.Header{}
+		if calls == 1 {
+			if got := query.Get("ContinuationToken"); got != "" {
+				t.Errorf(errfmt, "first ContinuationToken", "", got)
+			}
+			header.Set("X-MS-ContinuationToken", "page2")
+		} else if got := query.Get("ContinuationToken"); got != "page2" {
+			t.Errorf(errfmt, "second ContinuationToken", "page2", got)
+		}
+		data, e := ioutil.ReadFile("./fixtures/registrationsResult.xml")
+		if e != nil {
+			return nil, nil, e
+		}
+		return data, &http.Response{StatusCode: http.StatusOK, Header: header}, nil
+	}
+
+	var devices []string
+	err := nhub.EachRegistration(context.Background(), 4, func(r *RegistrationResult) error {
+		devices = append(devices, r.RegistrationContent.RegisteredDevice.DeviceID)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if calls != 2 {
+		t.Errorf(errfmt, "pages", 2, calls)
+	}
+	if len(devices) != 8 || devices[0] != "ABCDEF" || devices[4] != "ABCDEF" {
+		t.Errorf(errfmt, "devices", "8 devices over 2 pages", devices)
+	}
+}
+
+func Test_EachRegistrationStopsOnCallbackError(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		stop             = errors.New("stop")
+		seen             int
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		header := http.Header{}
+		header.Set("X-MS-ContinuationToken", "next")
+		data, e := ioutil.ReadFile("./fixtures/registrationsResult.xml")
+		return data, &http.Response{StatusCode: http.StatusOK, Header: header}, e
+	}
+
+	err := nhub.EachRegistration(context.Background(), 0, func(r *RegistrationResult) error {
+		seen++
+		if seen == 2 {
+			return stop
+		}
+		return nil
+	})
+
+	if err != stop {
+		t.Errorf(errfmt, "error", stop, err)
+	}
+	if seen != 2 {
+		t.Errorf(errfmt, "registrations seen", 2, seen)
+	}
+}
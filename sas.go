@@ -0,0 +1,94 @@
+package notificationhubs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const sasTokenPrefix = "SharedAccessSignature "
+
+// SasToken is a parsed shared access signature token
+type SasToken struct {
+	// Resource is the URI the token grants access to, including everything below it
+	Resource string
+	// KeyName is the name of the key the token was signed with
+	KeyName string
+	// Signature is the base64 HMAC-SHA256 signature
+	Signature string
+	// Expiry is when the token stops being valid
+	Expiry time.Time
+}
+
+// GenerateSasToken mints a SAS token for resourceURI signed with the key keyName, valid for ttl.
+// resourceURI is the namespace, a hub, or a path below it such as a single installation,
+// for instance https://mynamespace.servicebus.windows.net/myhub/installations/id.
+func GenerateSasToken(resourceURI, keyName, key string, ttl time.Duration) (string, error) {
+	switch {
+	case resourceURI == "":
+		return "", NewValidationError("resourceURI", "is required", resourceURI)
+	case keyName == "":
+		return "", NewValidationError("keyName", "is required", keyName)
+	case key == "":
+		return "", NewValidationError("key", "is required", nil)
+	case ttl <= 0:
+		return "", NewValidationError("ttl", "must be positive", ttl)
+	}
+	return signSasToken(strings.ToLower(resourceURI), keyName, key, time.Now().Add(ttl).Unix()), nil
+}
+
+// ParseSasToken parses a SAS token, with or without its SharedAccessSignature prefix.
+// The signature is not checked, see Validate.
+func ParseSasToken(token string) (*SasToken, error) {
+	params, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(token), sasTokenPrefix))
+	if err != nil {
+		return nil, NewValidationError("token", "is not a SAS token", nil)
+	}
+	for _, field := range []string{"sr", "sig", "se", "skn"} {
+		if params.Get(field) == "" {
+			return nil, NewValidationError("token", "is missing "+field, nil)
+		}
+	}
+	expires, err := strconv.ParseInt(params.Get("se"), 10, 64)
+	if err != nil {
+		return nil, NewValidationError("token", "has an invalid expiry", params.Get("se"))
+	}
+	return &SasToken{
+		Resource:  params.Get("sr"),
+		KeyName:   params.Get("skn"),
+		Signature: params.Get("sig"),
+		Expiry:    time.Unix(expires, 0),
+	}, nil
+}
+
+// Validate checks that the token was signed with key and has not expired
+func (t *SasToken) Validate(key string) error {
+	expected := sasSignature(t.Resource, key, t.Expiry.Unix())
+	if !hmac.Equal([]byte(expected), []byte(t.Signature)) {
+		return NewError(ErrorCodeAuthenticationFailed, "SAS token signature is invalid")
+	}
+	if !time.Now().Before(t.Expiry) {
+		return NewError(ErrorCodeAuthenticationFailed, fmt.Sprintf("SAS token expired at %s", t.Expiry.UTC().Format(time.RFC3339)))
+	}
+	return nil
+}
+
+// Covers tells whether the token grants access to resourceURI,
+// which is its resource or a path below it
+func (t *SasToken) Covers(resourceURI string) bool {
+	resource := strings.TrimSuffix(strings.ToLower(t.Resource), "/")
+	target := strings.ToLower(resourceURI)
+	return target == resource || strings.HasPrefix(target, resource+"/")
+}
+
+// sasSignature returns the signature of a token for targetURI valid until expires
+func sasSignature(targetURI, key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fmt.Sprintf("%s\n%d", url.QueryEscape(targetURI), expires)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,79 @@
+package notificationhubs_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+const listenResource = "https://TestHub-ns.servicebus.windows.net/testhub"
+
+func Test_GenerateAndValidateSasToken(t *testing.T) {
+	token, err := GenerateSasToken(listenResource, "DefaultListenSharedAccessSignature", "listenKey", time.Hour)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if !strings.HasPrefix(token, "SharedAccessSignature ") {
+		t.Errorf(errfmt, "token prefix", "SharedAccessSignature", token)
+	}
+
+	parsed, err := ParseSasToken(token)
+	if err != nil {
+		t.Fatalf(errfmt, "parse error", nil, err)
+	}
+	if parsed.Resource != strings.ToLower(listenResource) || parsed.KeyName != "DefaultListenSharedAccessSignature" {
+		t.Errorf(errfmt, "parsed token", listenResource, parsed)
+	}
+	if until := time.Until(parsed.Expiry); until <= 59*time.Minute || until > time.Hour {
+		t.Errorf(errfmt, "expiry", "in an hour", parsed.Expiry)
+	}
+	if err = parsed.Validate("listenKey"); err != nil {
+		t.Errorf(errfmt, "validation", nil, err)
+	}
+	if !parsed.Covers(listenResource+"/installations/device-1") || parsed.Covers("https://testhub-ns.servicebus.windows.net/otherhub") {
+		t.Errorf(errfmt, "covered resources", "the hub and below", parsed.Resource)
+	}
+}
+
+func Test_SasTokenValidationFailures(t *testing.T) {
+	var hubErr *NotificationHubError
+
+	token, _ := GenerateSasToken(listenResource, "listen", "listenKey", time.Hour)
+	parsed, _ := ParseSasToken(token)
+	if err := parsed.Validate("otherKey"); !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeAuthenticationFailed {
+		t.Errorf(errfmt, "wrong key", ErrorCodeAuthenticationFailed, err)
+	}
+
+	parsed.Resource = listenResource + "/installations"
+	if err := parsed.Validate("listenKey"); err == nil {
+		t.Errorf(errfmt, "tampered resource", "error", err)
+	}
+
+	expired, _ := GenerateSasToken(listenResource, "listen", "listenKey", time.Nanosecond)
+	parsed, _ = ParseSasToken(expired)
+	if err := parsed.Validate("listenKey"); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf(errfmt, "expired token", "expired", err)
+	}
+}
+
+func Test_ParseSasTokenInvalid(t *testing.T) {
+	for _, token := range []string{
+		"",
+		"SharedAccessSignature sr=x&sig=y&skn=z",
+		"SharedAccessSignature " + url.Values{"sr": {"x"}, "sig": {"y"}, "skn": {"z"}, "se": {"soon"}}.Encode(),
+	} {
+		var validationErr *ValidationError
+		if _, err := ParseSasToken(token); !errors.As(err, &validationErr) {
+			t.Errorf(errfmt, "parse error of "+token, "ValidationError", err)
+		}
+	}
+
+	var validationErr *ValidationError
+	if _, err := GenerateSasToken(listenResource, "listen", "key", 0); !errors.As(err, &validationErr) || validationErr.Field != "ttl" {
+		t.Errorf(errfmt, "ttl error", "ValidationError", err)
+	}
+}
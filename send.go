@@ -5,9 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"net/textproto"
 	"net/url"
 	"path"
@@ -20,27 +20,36 @@ import (
 // ex. "(follows_RedSox || follows_Cardinals) && location_Boston"
 // or nil if no tags should be used
 func (h *NotificationHub) Send(ctx context.Context, n *Notification, tags *string) (raw []byte, telemetry *NotificationTelemetry, err error) {
-	raw, telemetry, err = h.send(ctx, n, tags, nil)
+	ctx, span := h.startSpan(ctx, opSend)
+	defer func() { endSpan(span, err) }()
+
+	raw, telemetry, err = h.send(ctx, opSend, n, tags, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("notificationhubs.SendDirect: %s", err)
+		return nil, nil, err
 	}
 	return
 }
 
 // SendDirect publishes notification to a specific device
 func (h *NotificationHub) SendDirect(ctx context.Context, n *Notification, deviceHandle string) (raw []byte, telemetry *NotificationTelemetry, err error) {
+	ctx, span := h.startSpan(ctx, opSendDirect)
+	defer func() { endSpan(span, err) }()
+
 	raw, telemetry, err = h.sendDirect(ctx, n, deviceHandle)
 	if err != nil {
-		return nil, nil, fmt.Errorf("notificationhubs.SendDirect: %s", err)
+		return nil, nil, err
 	}
 	return
 }
 
 // SendDirectBatch publishes notification to a collection of devices
 func (h *NotificationHub) SendDirectBatch(ctx context.Context, n *Notification, deviceHandles ...string) (raw []byte, telemetry *NotificationTelemetry, err error) {
+	ctx, span := h.startSpan(ctx, opSendDirectBatch)
+	defer func() { endSpan(span, err) }()
+
 	raw, telemetry, err = h.sendDirectBatch(ctx, n, deviceHandles)
 	if err != nil {
-		return nil, nil, fmt.Errorf("notificationhubs.SendDirectBatch: %s", err)
+		return nil, nil, newOperationError(opSendDirectBatch, ErrorCodeInvalidPayload, err)
 	}
 	return
 }
@@ -49,21 +58,24 @@ func (h *NotificationHub) SendDirectBatch(ctx context.Context, n *Notification,
 // Format tags according to https://docs.microsoft.com/en-us/azure/notification-hubs/notification-hubs-tags-segment-push-message
 // or nil if no tags should be used
 func (h *NotificationHub) Schedule(ctx context.Context, n *Notification, tags *string, deliverTime time.Time) (raw []byte, telemetry *NotificationTelemetry, err error) {
-	raw, telemetry, err = h.send(ctx, n, tags, &deliverTime)
+	ctx, span := h.startSpan(ctx, opSchedule)
+	defer func() { endSpan(span, err) }()
+
+	raw, telemetry, err = h.send(ctx, opSchedule, n, tags, &deliverTime)
 	if err != nil {
-		return nil, nil, fmt.Errorf("notificationhubs.Schedule: %s", err)
+		return nil, nil, err
 	}
 	return
 }
 
 // send sends notification to the azure hub
-func (h *NotificationHub) send(ctx context.Context, n *Notification, tags *string, deliverTime *time.Time) (raw []byte, telemetry *NotificationTelemetry, err error) {
+func (h *NotificationHub) send(ctx context.Context, op operation, n *Notification, tags *string, deliverTime *time.Time) (raw []byte, telemetry *NotificationTelemetry, err error) {
 	var (
-		headers = map[string]string{
-			"Content-Type":                  n.Format.GetContentType(),
+		headers = n.headers(Headers{
+			"Content-Type":                  n.GetContentType(),
 			"ServiceBusNotification-Format": string(n.Format),
-			"X-Apns-Expiration":             strconv.FormatInt(h.expirationTimeGenerator.GenerateTimestamp(), 10), //apns-expiration
-		}
+			"X-Apns-Expiration":             h.apnsExpiration(ctx, n), //apns-expiration
+		})
 		_url = h.generateAPIURL("")
 	)
 
@@ -87,101 +99,138 @@ func (h *NotificationHub) send(ctx context.Context, n *Notification, tags *strin
 			_url.Path = path.Join(_url.Path, "schedulednotifications")
 			headers["ServiceBusNotification-ScheduleTime"] = deliverTime.Format("2006-01-02T15:04:05")
 		} else {
-			return nil, nil, errors.New("you can not schedule a notification in the past")
+			return nil, nil, newOperationError(op, ErrorCodeInvalidRequest, errors.New("you can not schedule a notification in the past"))
 		}
 	} else {
 		_url.Path = path.Join(_url.Path, "messages")
 	}
 
-	raw, response, err := h.exec(ctx, postMethod, _url, headers, bytes.NewBuffer(n.Payload))
+	raw, response, err := h.exec(withAPIFeature(ctx, n.Format.apiFeature()), op, postMethod, _url, headers, bytes.NewBuffer(n.Payload))
 	if err != nil {
 		return
 	}
-	telemetry, err = NewNotificationTelemetryFromHTTPResponse(response)
+	telemetry, err = h.telemetry(ctx, op, response)
 	return
 }
 
 func (h *NotificationHub) sendDirect(ctx context.Context, n *Notification, deviceHandle string) (raw []byte, telemetry *NotificationTelemetry, err error) {
+	cfg := h.configFrom(ctx)
 	var (
-		headers = Headers{
-			"Content-Type":                        n.Format.GetContentType(),
+		headers = n.headers(Headers{
+			"Content-Type":                        n.GetContentType(),
 			"ServiceBusNotification-Format":       string(n.Format),
 			"ServiceBusNotification-DeviceHandle": deviceHandle,
-			"X-Apns-Expiration":                   strconv.FormatInt(h.expirationTimeGenerator.GenerateTimestamp(), 10), //apns-expiration
-		}
-		query = h.HubURL.Query()
+			"X-Apns-Expiration":                   h.apnsExpiration(ctx, n), //apns-expiration
+		})
+		query = cfg.hubURL.Query()
 	)
 	query.Add(directParam, "")
 	_url := &url.URL{
-		Host:     h.HubURL.Host,
-		Scheme:   h.HubURL.Scheme,
-		Path:     path.Join(h.HubURL.Path, "messages"),
+		Host:     cfg.hubURL.Host,
+		Scheme:   cfg.hubURL.Scheme,
+		Path:     path.Join(cfg.hubURL.Path, "messages"),
 		RawQuery: query.Encode(),
 	}
-	raw, response, err := h.exec(ctx, postMethod, _url, headers, bytes.NewBuffer(n.Payload))
+	raw, response, err := h.exec(withAPIFeature(ctx, n.Format.apiFeature()), opSendDirect, postMethod, _url, headers, bytes.NewBuffer(n.Payload))
 	if err != nil {
 		return
 	}
-	telemetry, err = NewNotificationTelemetryFromHTTPResponse(response)
+	telemetry, err = h.telemetry(ctx, opSendDirect, response)
 	return
 }
 
 func (h *NotificationHub) sendDirectBatch(ctx context.Context, n *Notification, deviceHandles []string) (raw []byte, telemetry *NotificationTelemetry, err error) {
 	if len(deviceHandles) > 1000 {
-		err = errors.New("you can not batch send to more than 1,000 devices")
+		err = newOperationError(opSendDirectBatch, ErrorCodeInvalidRequest, errors.New("you can not batch send to more than 1,000 devices"))
 		return
 	}
 
-	buf := &bytes.Buffer{}
-	multi := multipart.NewWriter(buf)
-
-	var part io.Writer
-	part, err = multi.CreatePart(textproto.MIMEHeader{
-		"Content-Type":        []string{n.Format.GetContentType()},
-		"Content-Disposition": []string{"inline; name=notification"},
-	})
-	if err != nil {
-		return
-	}
-	if _, err = part.Write(n.Payload); err != nil {
-		return
+	cfg := h.configFrom(ctx)
+	if cfg.metrics != nil {
+		cfg.metrics.ObserveBatchSize(len(deviceHandles))
 	}
 
-	part, err = multi.CreatePart(textproto.MIMEHeader{
-		"Content-Type":        []string{"application/json"},
-		"Content-Disposition": []string{"inline; name=devices"},
-	})
-	if err != nil {
-		return
-	}
 	var handles []byte
 	handles, err = json.Marshal(deviceHandles)
 	if err != nil {
 		return
 	}
-	if _, err = part.Write(handles); err != nil {
+
+	// the body is streamed, every copy of it must share the same boundary
+	form := multipart.NewWriter(io.Discard)
+	var body *pipeBody
+	body, err = newPipeBody(func(w io.Writer) error {
+		multi := multipart.NewWriter(w)
+		if err := multi.SetBoundary(form.Boundary()); err != nil {
+			return err
+		}
+		part, err := multi.CreatePart(textproto.MIMEHeader{
+			"Content-Type":        []string{n.GetContentType()},
+			"Content-Disposition": []string{"inline; name=notification"},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = part.Write(n.Payload); err != nil {
+			return err
+		}
+		part, err = multi.CreatePart(textproto.MIMEHeader{
+			"Content-Type":        []string{"application/json"},
+			"Content-Disposition": []string{"inline; name=devices"},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = part.Write(handles); err != nil {
+			return err
+		}
+		return multi.Close()
+	})
+	if err != nil {
 		return
 	}
 
 	var (
-		headers = Headers{
-			"Content-Type":                  multi.FormDataContentType(),
+		headers = n.headers(Headers{
+			"Content-Type":                  form.FormDataContentType(),
 			"ServiceBusNotification-Format": string(n.Format),
-			"X-Apns-Expiration":             strconv.FormatInt(h.expirationTimeGenerator.GenerateTimestamp(), 10), //apns-expiration
-		}
-		query = h.HubURL.Query()
+			"X-Apns-Expiration":             h.apnsExpiration(ctx, n), //apns-expiration
+		})
+		query = cfg.hubURL.Query()
 	)
 	query.Add(directParam, "")
 	_url := &url.URL{
-		Host:     h.HubURL.Host,
-		Scheme:   h.HubURL.Scheme,
-		Path:     path.Join(h.HubURL.Path, "messages", "$batch"),
+		Host:     cfg.hubURL.Host,
+		Scheme:   cfg.hubURL.Scheme,
+		Path:     path.Join(cfg.hubURL.Path, "messages", "$batch"),
 		RawQuery: query.Encode(),
 	}
-	raw, response, err := h.exec(ctx, postMethod, _url, headers, buf)
+	raw, response, err := h.exec(withAPIFeature(ctx, n.Format.apiFeature()), opSendDirectBatch, postMethod, _url, headers, body)
 	if err != nil {
 		return
 	}
-	telemetry, err = NewNotificationTelemetryFromHTTPResponse(response)
+	telemetry, err = h.telemetry(ctx, opSendDirectBatch, response)
 	return
 }
+
+// telemetry reads the notification telemetry of a send operation from its response
+func (h *NotificationHub) telemetry(ctx context.Context, op operation, response *http.Response) (*NotificationTelemetry, error) {
+	telemetry, err := NewNotificationTelemetryFromHTTPResponse(response)
+	if err != nil {
+		return nil, newOperationError(op, ErrorCodeInvalidResponse, err)
+	}
+	if telemetry != nil && telemetry.NotificationMessageID != "" {
+		spanFromContext(ctx).SetAttribute(AttributeNotificationID, telemetry.NotificationMessageID)
+	}
+	return telemetry, nil
+}
+
+// apnsExpiration returns the X-Apns-Expiration header value of n,
+// using the hub default when n has no TTL of its own
+func (h *NotificationHub) apnsExpiration(ctx context.Context, n *Notification) string {
+	ttl := n.ApnsTTL
+	if ttl <= 0 {
+		ttl = h.configFrom(ctx).apnsTTL
+	}
+	return strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+}
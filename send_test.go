@@ -311,6 +311,29 @@ func Test_NotificationHubSendIosBackgroundNotification(t *testing.T) {
 	}
 }
 
+func Test_NotificationHubSendAppleAlertThatWakesTheApp(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		notification, _  = NewNotification(AppleFormat, []byte(`{"aps":{"alert":"hi","content-available":1}}`))
+	)
+
+	mockClient.execFunc = func(obtainedReq *http.Request) ([]byte, *http.Response, error) {
+		if obtainedReq.Header.Get("X-Apns-Push-Type") != "alert" {
+			t.Errorf(errfmt, "X-Apns-Push-Type", "alert", obtainedReq.Header.Get("X-Apns-Push-Type"))
+		}
+		if obtainedReq.Header.Get("X-Apns-Priority") != "10" {
+			t.Errorf(errfmt, "X-Apns-Priority", "10", obtainedReq.Header.Get("X-Apns-Priority"))
+		}
+		header := http.Header{}
+		header.Set("Location", "https://messages.servicebus.windows.net/messagebus/messages/3288835312934927344-986564390439048203-1?api-version=2016-10")
+		return nil, &http.Response{Header: header}, nil
+	}
+
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+		t.Errorf(errfmt, "error", nil, err)
+	}
+}
+
 func Test_NotificationHubSendAppleAlertNotification(t *testing.T) {
 	var (
 		nhub, mockClient = initTestItems()
@@ -351,3 +374,63 @@ func Test_NotificationHubSendAppleAlertNotification(t *testing.T) {
 		t.Errorf(errfmt, "error", nil, err)
 	}
 }
+
+func Test_ApnsExpirationIndependentOfToken(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		apnsExpiration                 int64
+		tokenExpiration                string
+	)
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		apnsExpiration, _ = strconv.ParseInt(req.Header.Get("X-Apns-Expiration"), 10, 64)
+		token, _ := url.ParseQuery(strings.TrimPrefix(req.Header.Get("Authorization"), "SharedAccessSignature "))
+		tokenExpiration = token.Get("se")
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+	expectAround := func(name string, ttl time.Duration) {
+		t.Helper()
+		want := time.Now().Add(ttl).Unix()
+		if apnsExpiration < want-5 || apnsExpiration > want {
+			t.Errorf(errfmt, name, want, apnsExpiration)
+		}
+	}
+
+	if _, _, err := nhub.Send(context.Background(), notification, nil); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	expectAround("default APNs expiration", DefaultApnsTTL)
+	if tokenExpiration != "123" {
+		t.Errorf(errfmt, "token expiration", "123", tokenExpiration)
+	}
+
+	nhub = nhub.With(WithApnsTTL(2 * time.Hour))
+	_, _, _ = nhub.SendDirect(context.Background(), notification, "device")
+	expectAround("hub APNs expiration", 2*time.Hour)
+
+	notification.ApnsTTL = 10 * time.Minute
+	_, _, _ = nhub.SendDirectBatch(context.Background(), notification, "device")
+	expectAround("notification APNs expiration", 10*time.Minute)
+	if tokenExpiration != "123" {
+		t.Errorf(errfmt, "token expiration", "123", tokenExpiration)
+	}
+}
+
+func Test_TokenLifetime(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		tokenExpiration                int64
+	)
+	nhub = nhub.With(WithTokenLifetime(10 * time.Minute))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		token, _ := url.ParseQuery(strings.TrimPrefix(req.Header.Get("Authorization"), "SharedAccessSignature "))
+		tokenExpiration, _ = strconv.ParseInt(token.Get("se"), 10, 64)
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+
+	_, _, _ = nhub.Send(context.Background(), notification, nil)
+	if want := time.Now().Add(10 * time.Minute).Unix(); tokenExpiration < want-5 || tokenExpiration > want {
+		t.Errorf(errfmt, "token expiration", want, tokenExpiration)
+	}
+}
@@ -0,0 +1,112 @@
+package notificationhubs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+type (
+	// streamContextKey marks a request whose successful response body is returned unread
+	streamContextKey struct{}
+
+	// streamState records whether the HTTP client streamed the response
+	streamState struct {
+		streamed bool
+	}
+
+	// pipeBody is a request body written through an io.Pipe on first read,
+	// so it is never held in memory. It can be reopened to replay the request.
+	pipeBody struct {
+		write  func(w io.Writer) error
+		size   int64
+		reader *io.PipeReader
+	}
+
+	// countingWriter counts the bytes written to it
+	countingWriter struct {
+		n int64
+	}
+)
+
+// streamingClient executes requests marked for streaming through the Stream
+// method of client when it has one
+func streamingClient(client utils.HTTPClient) utils.HTTPClient {
+	streamer, ok := client.(utils.StreamingHTTPClient)
+	if !ok {
+		return client
+	}
+	return utils.HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+		state, ok := req.Context().Value(streamContextKey{}).(*streamState)
+		if !ok {
+			return streamer.Exec(req)
+		}
+		response, err := streamer.Stream(req)
+		if err == nil {
+			state.streamed = true
+		}
+		return nil, response, err
+	})
+}
+
+// stream executes a request like exec and returns the response body as a stream.
+// Clients without streaming support are read as usual. The caller must close the body.
+func (h *NotificationHub) stream(ctx context.Context, op operation, method string, url *url.URL, headers Headers) (io.ReadCloser, *http.Response, error) {
+	state := &streamState{}
+	raw, response, err := h.exec(context.WithValue(ctx, streamContextKey{}, state), op, method, url, headers, nil)
+	if err != nil {
+		if state.streamed && response != nil {
+			_ = response.Body.Close()
+		}
+		return nil, response, err
+	}
+	if state.streamed {
+		return response.Body, response, nil
+	}
+	return io.NopCloser(bytes.NewReader(raw)), response, nil
+}
+
+// newPipeBody returns a body written by write. write is called once up front
+// to measure the body and again every time the body is read.
+func newPipeBody(write func(w io.Writer) error) (*pipeBody, error) {
+	counter := &countingWriter{}
+	if err := write(counter); err != nil {
+		return nil, err
+	}
+	return &pipeBody{write: write, size: counter.n}, nil
+}
+
+// Read implements io.Reader, starting the writer on first use
+func (b *pipeBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		reader, writer := io.Pipe()
+		go func() {
+			_ = writer.CloseWithError(b.write(writer))
+		}()
+		b.reader = reader
+	}
+	return b.reader.Read(p)
+}
+
+// Close implements io.Closer, stopping the writer
+func (b *pipeBody) Close() error {
+	if b.reader == nil {
+		return nil
+	}
+	return b.reader.Close()
+}
+
+// reopen returns a fresh copy of the body, it is used as http.Request.GetBody
+func (b *pipeBody) reopen() (io.ReadCloser, error) {
+	return &pipeBody{write: b.write, size: b.size}, nil
+}
+
+// Write implements io.Writer
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,106 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+func Test_EachRegistrationStreamsBeyondResponseLimit(t *testing.T) {
+	fixture, err := os.ReadFile("./fixtures/registrationsResult.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(fixture)
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+"/;SharedAccessKeyName=name;SharedAccessKey=key", hubPath)
+	nhub.SetHTTPClient(utils.NewHubHTTPClient(utils.WithMaxResponseBytes(512)))
+
+	_, _, err = nhub.Registrations(context.Background())
+	var hubErr *NotificationHubError
+	if !errors.As(err, &hubErr) || hubErr.Code != ErrorCodeResponseTooLarge {
+		t.Errorf(errfmt, "Registrations error", ErrorCodeResponseTooLarge, err)
+	}
+
+	count := 0
+	err = nhub.EachRegistration(context.Background(), 100, func(r *RegistrationResult) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if count != 4 {
+		t.Errorf(errfmt, "registrations", 4, count)
+	}
+}
+
+func Test_SendDirectBatchStreamsReplayableBody(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength <= 0 {
+			t.Errorf(errfmt, "Content-Length", "> 0", r.ContentLength)
+		}
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf(errfmt, "Content-Type", "multipart", err)
+		}
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		parts := map[string]string{}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf(errfmt, "multipart body", nil, err)
+			}
+			b, _ := io.ReadAll(part)
+			_, disposition, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			parts[disposition["name"]] = string(b)
+		}
+		var devices []string
+		if err := json.Unmarshal([]byte(parts["devices"]), &devices); err != nil || len(devices) != 2 {
+			t.Errorf(errfmt, "devices part", `["a","b"]`, parts["devices"])
+		}
+		if parts["notification"] != "{}" {
+			t.Errorf(errfmt, "notification part", "{}", parts["notification"])
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	policy := utils.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	var (
+		nhub            = NewNotificationHub("Endpoint="+server.URL+"/;SharedAccessKeyName=name;SharedAccessKey=key", hubPath)
+		notification, _ = NewNotification(Template, []byte("{}"))
+	)
+	nhub.SetHTTPClient(utils.NewHubHTTPClient(utils.WithRetryPolicy(policy)))
+
+	if _, _, err := nhub.SendDirectBatch(context.Background(), notification, "a", "b"); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if calls != 2 {
+		t.Errorf(errfmt, "attempts", 2, calls)
+	}
+}
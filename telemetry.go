@@ -5,23 +5,24 @@ import (
 	"encoding/xml"
 	"errors"
 	"net/http"
-	"net/url"
 	"path"
 	"regexp"
 )
 
 // NotificationDetails reads one specific registration
 func (h *NotificationHub) NotificationDetails(ctx context.Context, notificationID string) (details *NotificationDetails, raw []byte, err error) {
+	ctx, span := h.startSpan(ctx, opNotificationDetails)
+	defer func() { endSpan(span, err) }()
+
 	var (
 		_url = h.generateAPIURL(path.Join("messages", notificationID))
 	)
-	_url.RawQuery = url.Values{apiVersionParam: {telemetryAPIVersionValue}}.Encode()
-	raw, _, err = h.exec(ctx, getMethod, _url, Headers{}, nil)
+	raw, _, err = h.exec(ctx, opNotificationDetails, getMethod, _url, Headers{}, nil)
 	if err != nil {
 		return
 	}
 	if err = xml.Unmarshal(raw, &details); err != nil {
-		return
+		err = newOperationError(opNotificationDetails, ErrorCodeInvalidResponse, err)
 	}
 	return
 }
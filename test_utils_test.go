@@ -16,6 +16,8 @@ const (
 	schedulesURL             = "https://testhub-ns.servicebus.windows.net/testhub/schedulednotifications?api-version=2016-07"
 	registrationsURL         = "https://testhub-ns.servicebus.windows.net/testhub/registrations?api-version=2016-07"
 	installationsURL         = "https://testhub-ns.servicebus.windows.net/testhub/installations?api-version=2016-07"
+	fcmV1RegistrationsURL    = "https://testhub-ns.servicebus.windows.net/testhub/registrations?api-version=2020-06"
+	fcmV1InstallationsURL    = "https://testhub-ns.servicebus.windows.net/testhub/installations?api-version=2020-06"
 	hubPath                  = "testhub"
 	apiVersionParam          = "api-version"
 	apiVersionValue          = "2016-07"
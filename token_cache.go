@@ -0,0 +1,44 @@
+package notificationhubs
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTokenRenewalSkew is how long before its expiry a cached SAS token is renewed
+const DefaultTokenRenewalSkew = 5 * time.Minute
+
+// sasTokenCache keeps the last SAS token until it is close to expiry.
+// The token is bound to the key and URI it was signed for,
+// so a rotated key is never served a stale signature.
+type sasTokenCache struct {
+	mu       sync.Mutex
+	token    string
+	expires  int64
+	keyName  string
+	keyValue string
+	uri      string
+}
+
+// get returns the cached token for the key and uri, calling generate when
+// there is none or it expires within skew. Concurrent callers wait for a single renewal.
+func (c *sasTokenCache) get(keyName, keyValue, uri string, skew time.Duration, generate func() (string, int64)) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.keyName == keyName && c.keyValue == keyValue && c.uri == uri &&
+		time.Now().Add(skew).Unix() < c.expires {
+		return c.token
+	}
+
+	c.token, c.expires = generate()
+	c.keyName, c.keyValue, c.uri = keyName, keyValue, uri
+	return c.token
+}
+
+// invalidate drops the cached token, so the next request signs a new one
+func (c *sasTokenCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.expires = "", 0
+}
@@ -0,0 +1,123 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+	"github.com/koreset/azure-notificationhubs-sdk-go/utils"
+)
+
+func initTokenCacheTestItems() (*NotificationHub, *int32, func() []string) {
+	var (
+		nhub, mockClient = initTestItems()
+		generated        int32
+		mu               sync.Mutex
+		tokens           []string
+	)
+	nhub.SetExpirationTimeGenerator(utils.ExpirationTimeGeneratorFunc(func() int64 {
+		atomic.AddInt32(&generated, 1)
+		return time.Now().Add(time.Hour).Unix()
+	}))
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		tokens = append(tokens, req.Header.Get("Authorization"))
+		return nil, nil, nil
+	}
+	return nhub, &generated, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), tokens...)
+	}
+}
+
+func Test_SasTokenIsCached(t *testing.T) {
+	var nhub, generated, tokens = initTokenCacheTestItems()
+
+	for i := 0; i < 3; i++ {
+		_ = nhub.Uninstall(context.Background(), "id")
+	}
+	if *generated != 1 {
+		t.Errorf(errfmt, "signed tokens", 1, *generated)
+	}
+	got := tokens()
+	if got[0] != got[1] || got[1] != got[2] {
+		t.Errorf(errfmt, "tokens", "the same token", got)
+	}
+
+	nhub.SetSasKeys(SasKey{Name: nhub.SasKeyName, Value: "rotatedKey"}, SasKey{})
+	_ = nhub.Uninstall(context.Background(), "id")
+	if *generated != 2 {
+		t.Errorf(errfmt, "signed tokens after key rotation", 2, *generated)
+	}
+	if got = tokens(); got[3] == got[2] {
+		t.Errorf(errfmt, "token after key rotation", "a new token", got[3])
+	}
+}
+
+func Test_SasTokenDroppedWhenLifetimeChanges(t *testing.T) {
+	var nhub, generated, tokens = initTokenCacheTestItems()
+
+	_ = nhub.Uninstall(context.Background(), "id")
+	nhub.SetExpirationTimeGenerator(utils.ExpirationTimeGeneratorFunc(func() int64 {
+		atomic.AddInt32(generated, 1)
+		return time.Now().Add(2 * time.Hour).Unix()
+	}))
+	_ = nhub.Uninstall(context.Background(), "id")
+	if *generated != 2 {
+		t.Errorf(errfmt, "signed tokens after a new generator", 2, *generated)
+	}
+
+	nhub = nhub.With(WithTokenLifetime(10 * time.Minute))
+	_ = nhub.Uninstall(context.Background(), "id")
+	got := tokens()
+	params, _ := url.ParseQuery(strings.TrimPrefix(got[2], "SharedAccessSignature "))
+	expires, _ := strconv.ParseInt(params.Get("se"), 10, 64)
+	if want := time.Now().Add(10 * time.Minute).Unix(); got[2] == got[1] || expires < want-5 || expires > want {
+		t.Errorf(errfmt, "token after a new lifetime", "a token valid for 10 minutes", got[2])
+	}
+}
+
+func Test_SasTokenRenewedNearExpiry(t *testing.T) {
+	var nhub, generated, _ = initTokenCacheTestItems()
+	nhub = nhub.With(WithTokenRenewalSkew(2 * time.Hour))
+
+	_ = nhub.Uninstall(context.Background(), "id")
+	_ = nhub.Uninstall(context.Background(), "id")
+	if *generated != 2 {
+		t.Errorf(errfmt, "signed tokens", 2, *generated)
+	}
+}
+
+func Test_SasTokenConcurrentCallers(t *testing.T) {
+	var (
+		nhub, generated, tokens = initTokenCacheTestItems()
+		wg                      sync.WaitGroup
+	)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = nhub.Uninstall(context.Background(), "id")
+		}()
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(generated) != 1 {
+		t.Errorf(errfmt, "signed tokens", 1, *generated)
+	}
+	for _, token := range tokens() {
+		if token != tokens()[0] {
+			t.Fatalf(errfmt, "token", tokens()[0], token)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package notificationhubs
+
+import (
+	"context"
+	"net/http"
+)
+
+// Span attribute keys set on every hub operation span
+const (
+	AttributeOperation           = "notificationhubs.operation"
+	AttributeHubPath             = "notificationhubs.hub_path"
+	AttributeNotificationFormat  = "notificationhubs.notification_format"
+	AttributeTagExpressionLength = "notificationhubs.tag_expression_length"
+	AttributeHTTPStatusCode      = "http.status_code"
+	AttributeTrackingID          = "notificationhubs.tracking_id"
+	AttributeNotificationID      = "notificationhubs.notification_id"
+)
+
+type (
+	// Tracer starts a span for every public hub operation.
+	// Implement it to bridge the hub to OpenTelemetry or another tracing system.
+	Tracer interface {
+		Start(ctx context.Context, name string) (context.Context, Span)
+	}
+
+	// TracePropagator is optionally implemented by a Tracer
+	// to inject the trace context of ctx into outgoing request headers
+	TracePropagator interface {
+		Inject(ctx context.Context, header http.Header)
+	}
+
+	// Span is a single traced hub operation
+	Span interface {
+		SetAttribute(key string, value interface{})
+		RecordError(err error)
+		End()
+	}
+
+	// noopSpan is used when no tracer is set
+	noopSpan struct{}
+
+	// spanContextKey is the context key of the current operation span
+	spanContextKey struct{}
+)
+
+// SetAttribute does nothing
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+// RecordError does nothing
+func (noopSpan) RecordError(error) {}
+
+// End does nothing
+func (noopSpan) End() {}
+
+// startSpan opens the span of op and stores it in the returned context,
+// along with the configuration snapshot the operation runs with
+func (h *NotificationHub) startSpan(ctx context.Context, op operation) (context.Context, Span) {
+	ctx, cfg := h.snapshot(ctx)
+	if cfg.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := cfg.tracer.Start(ctx, "notificationhubs."+string(op))
+	span.SetAttribute(AttributeOperation, string(op))
+	span.SetAttribute(AttributeHubPath, cfg.hubURL.Path)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// endSpan records err on span and ends it
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// spanFromContext returns the operation span stored in ctx
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanContextKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// traceRequest annotates the operation span with the request and propagates the trace context
+func (cfg *hubConfig) traceRequest(ctx context.Context, req *http.Request) {
+	span := spanFromContext(ctx)
+	if format := req.Header.Get("ServiceBusNotification-Format"); format != "" {
+		span.SetAttribute(AttributeNotificationFormat, format)
+	}
+	if tags := req.Header.Get("ServiceBusNotification-Tags"); tags != "" {
+		span.SetAttribute(AttributeTagExpressionLength, len(tags))
+	}
+	if propagator, ok := cfg.tracer.(TracePropagator); ok {
+		propagator.Inject(ctx, req.Header)
+	}
+}
+
+// traceResponse annotates the operation span with the hub response
+func traceResponse(ctx context.Context, response *http.Response) {
+	if response == nil {
+		return
+	}
+	span := spanFromContext(ctx)
+	span.SetAttribute(AttributeHTTPStatusCode, response.StatusCode)
+	if trackingID := response.Header.Get("TrackingId"); trackingID != "" {
+		span.SetAttribute(AttributeTrackingID, trackingID)
+	}
+}
@@ -0,0 +1,118 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+type traceparentKey struct{}
+
+type mockSpan struct {
+	name       string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *mockSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *mockSpan) RecordError(err error)                      { s.err = err }
+func (s *mockSpan) End()                                       { s.ended = true }
+
+type mockTracer struct {
+	mu    sync.Mutex
+	spans []*mockSpan
+}
+
+func (tr *mockTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	span := &mockSpan{name: name, attributes: map[string]interface{}{}}
+	tr.spans = append(tr.spans, span)
+	return context.WithValue(ctx, traceparentKey{}, "00-trace-span-01"), span
+}
+
+func (tr *mockTracer) Inject(ctx context.Context, header http.Header) {
+	if traceparent, ok := ctx.Value(traceparentKey{}).(string); ok {
+		header.Set("traceparent", traceparent)
+	}
+}
+
+func Test_TracingSend(t *testing.T) {
+	var (
+		nhub, notification, mockClient = initNotificationTestItems()
+		tracer                         = &mockTracer{}
+		tags                           = "tag1 || tag2"
+	)
+	nhub = nhub.With(WithTracer(tracer))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		if got := req.Header.Get("traceparent"); got != "00-trace-span-01" {
+			t.Errorf(errfmt, "traceparent header", "00-trace-span-01", got)
+		}
+		header := http.Header{}
+		header.Set("TrackingId", "tracking-123")
+		header.Set("Location", "https://testhub-ns.servicebus.windows.net/testhub/messages/123456?api-version=2016-07")
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: header}, nil
+	}
+
+	if _, _, err := nhub.Send(context.Background(), notification, &tags); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf(errfmt, "spans", 1, len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "notificationhubs.Send" {
+		t.Errorf(errfmt, "span name", "notificationhubs.Send", span.name)
+	}
+	if !span.ended {
+		t.Errorf(errfmt, "span ended", true, span.ended)
+	}
+	expected := map[string]interface{}{
+		AttributeOperation:           "Send",
+		AttributeHubPath:             hubPath,
+		AttributeNotificationFormat:  string(Template),
+		AttributeTagExpressionLength: len(tags),
+		AttributeHTTPStatusCode:      http.StatusCreated,
+		AttributeTrackingID:          "tracking-123",
+		AttributeNotificationID:      "123456",
+	}
+	for key, want := range expected {
+		if got := span.attributes[key]; got != want {
+			t.Errorf(errfmt, key, want, got)
+		}
+	}
+}
+
+func Test_TracingRecordsErrors(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		tracer           = &mockTracer{}
+	)
+	nhub = nhub.With(WithTracer(tracer))
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		return statusErrorResponse(http.StatusNotFound, nil, "")
+	}
+
+	err := nhub.Uninstall(context.Background(), "id")
+	if len(tracer.spans) != 1 {
+		t.Fatalf(errfmt, "spans", 1, len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "notificationhubs.Uninstall" || !span.ended {
+		t.Errorf(errfmt, "span", "ended notificationhubs.Uninstall", span.name)
+	}
+	if !errors.Is(span.err, err) {
+		t.Errorf(errfmt, "recorded error", err, span.err)
+	}
+	if got := span.attributes[AttributeHTTPStatusCode]; got != http.StatusNotFound {
+		t.Errorf(errfmt, "status code", http.StatusNotFound, got)
+	}
+}
@@ -15,6 +15,8 @@ type (
 		NotificationFormat NotificationFormat `json:"service,omitempty"`
 		RegistrationID     string             `json:"registrationID,omitempty"`
 		Tags               string             `json:"tags,omitempty"`
+		// BaiduUserID is required for Baidu registrations, DeviceID is the Baidu channel id
+		BaiduUserID string `json:"baiduUserID,omitempty"`
 	}
 
 	// TemplateRegistration is a device registration to the hub supporting a template
@@ -25,6 +27,8 @@ type (
 		Tags           string         `json:"tags,omitempty"`
 		Platform       TargetPlatform `json:"platform,omitempty"`
 		Template       string         `json:"template,omitempty"`
+		// BaiduUserID is required for Baidu registrations, DeviceID is the Baidu channel id
+		BaiduUserID string `json:"baiduUserID,omitempty"`
 	}
 
 	// Registrations is a list of RegistrationResults
@@ -54,6 +58,10 @@ type (
 		AppleTemplateRegistrationDescription *RegisteredDevice `xml:"AppleTemplateRegistrationDescription"  json:"-"`
 		FcmV1RegistrationDescription         *RegisteredDevice `xml:"FcmV1RegistrationDescription"          json:"-"`
 		FcmV1TemplateRegistrationDescription *RegisteredDevice `xml:"FcmV1TemplateRegistrationDescription"  json:"-"`
+		AdmRegistrationDescription           *RegisteredDevice `xml:"AdmRegistrationDescription"            json:"-"`
+		AdmTemplateRegistrationDescription   *RegisteredDevice `xml:"AdmTemplateRegistrationDescription"    json:"-"`
+		BaiduRegistrationDescription         *RegisteredDevice `xml:"BaiduRegistrationDescription"          json:"-"`
+		BaiduTemplateRegistrationDescription *RegisteredDevice `xml:"BaiduTemplateRegistrationDescription"  json:"-"`
 	}
 
 	// RegisteredDevice is a device registration to the hub
@@ -64,10 +72,14 @@ type (
 		Template       string     `xml:"BodyTemplate"   json:"template,omitempty"`
 		RegistrationID string     `xml:"RegistrationId" json:"registrationID,omitempty"`
 		Tags           []string   `xml:"-"              json:"tags,omitempty"`
+		// BaiduUserID is the Baidu user id of Baidu registrations
+		BaiduUserID string `xml:"BaiduUserId" json:"baiduUserID,omitempty"`
 
 		DeviceToken          *string `xml:"DeviceToken"        json:"-"`
 		ExpirationTimeString *string `xml:"ExpirationTime"     json:"-"`
 		FcmV1RegistrationID  *string `xml:"FcmV1RegistrationId" json:"-"`
+		AdmRegistrationID    *string `xml:"AdmRegistrationId"   json:"-"`
+		BaiduChannelID       *string `xml:"BaiduChannelId"      json:"-"`
 		TagsString           *string `xml:"Tags"               json:"-"`
 	}
 
@@ -118,6 +130,8 @@ type (
 		TargetPlatforms    string                `xml:"TargetPlatforms"`
 		ApnsOutcomeCounts  *NotificationOutcomes `xml:"ApnsOutcomeCounts"`
 		FcmV1OutcomeCounts *NotificationOutcomes `xml:"FcmV1OutcomeCounts"`
+		AdmOutcomeCounts   *NotificationOutcomes `xml:"AdmOutcomeCounts"`
+		BaiduOutcomeCounts *NotificationOutcomes `xml:"BaiduOutcomeCounts"`
 	}
 
 	// NotificationTelemetry is the id of a sent or scheduled message
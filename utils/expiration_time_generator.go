@@ -2,6 +2,9 @@ package utils
 
 import "time"
 
+// DefaultTokenLifetime is the lifetime of the tokens signed with the default generator
+const DefaultTokenLifetime = time.Hour
+
 type (
 	// ExpirationTimeGenerator generates an expiration time
 	ExpirationTimeGenerator interface {
@@ -14,15 +17,17 @@ type (
 
 // NewExpirationTimeGenerator creates the default generator
 func NewExpirationTimeGenerator() ExpirationTimeGenerator {
-	return ExpirationTimeGeneratorFunc(generateExpirationTimestamp)
+	return NewExpirationTimeGeneratorWithLifetime(DefaultTokenLifetime)
+}
+
+// NewExpirationTimeGeneratorWithLifetime creates a generator of timestamps lifetime from now
+func NewExpirationTimeGeneratorWithLifetime(lifetime time.Duration) ExpirationTimeGenerator {
+	return ExpirationTimeGeneratorFunc(func() int64 {
+		return time.Now().Add(lifetime).Unix()
+	})
 }
 
 // GenerateTimestamp calls f()
 func (f ExpirationTimeGeneratorFunc) GenerateTimestamp() int64 {
 	return f()
 }
-
-// generateExpirationTimestamp generates token expiration timestamp value
-func generateExpirationTimestamp() int64 {
-	return time.Now().Unix() + int64(3600)
-}
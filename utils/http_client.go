@@ -2,37 +2,123 @@ package utils
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"time"
 )
 
+// DefaultMaxResponseBytes is the default limit of a response body read by HubHTTPClient
+const DefaultMaxResponseBytes int64 = 32 << 20
+
+// DefaultTimeout is the default limit of a single request attempt made by HubHTTPClient
+const DefaultTimeout = 60 * time.Second
+
 type (
 	// HTTPClient interface, replaceable for testing or custom implementation
 	HTTPClient interface {
 		Exec(req *http.Request) ([]byte, *http.Response, error)
 	}
 
+	// StreamingHTTPClient is an HTTPClient which can also return a successful
+	// response with its body unread. The caller must close the body.
+	StreamingHTTPClient interface {
+		HTTPClient
+		Stream(req *http.Request) (*http.Response, error)
+	}
+
+	// HTTPClientFunc is a function executing requests like an HTTPClient
+	HTTPClientFunc func(req *http.Request) ([]byte, *http.Response, error)
+
 	// HubHTTPClient is the internal HTTPClient
 	HubHTTPClient struct {
-		httpClient *http.Client
+		httpClient       *http.Client
+		ownTransport     bool
+		retryPolicy      *RetryPolicy
+		maxResponseBytes int64
+	}
+
+	// HubHTTPClientOption configures a HubHTTPClient
+	HubHTTPClientOption func(*HubHTTPClient)
+
+	// StatusError is returned when the hub responds with an unexpected status code
+	StatusError struct {
+		Response *http.Response
+		Body     []byte
+	}
+
+	// ResponseTooLargeError is returned when a response body exceeds the client limit
+	ResponseTooLargeError struct {
+		Response *http.Response
+		Limit    int64
 	}
 )
 
-// NewHubHTTPClient is creating the default client
-func NewHubHTTPClient() HTTPClient {
-	return HubHTTPClient{
-		httpClient: &http.Client{},
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Got unexpected response status code: %d. response: %s", e.Response.StatusCode, string(e.Body))
+}
+
+// Error implements the error interface
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("Response body exceeds the limit of %d bytes", e.Limit)
+}
+
+// WithMaxResponseBytes limits the size of a response body read by the client.
+// A limit of 0 or less disables it.
+func WithMaxResponseBytes(n int64) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		hc.maxResponseBytes = n
 	}
 }
 
-// Exec executes notification hub http request and handles the response
+// NewHubHTTPClient is creating the default client, each attempt times out after DefaultTimeout.
+// Options configure retries, timeouts, connection pooling, proxy and TLS settings.
+func NewHubHTTPClient(opts ...HubHTTPClientOption) HTTPClient {
+	hc := HubHTTPClient{
+		httpClient:       &http.Client{Timeout: DefaultTimeout},
+		maxResponseBytes: DefaultMaxResponseBytes,
+	}
+	for _, opt := range opts {
+		opt(&hc)
+	}
+	return hc
+}
+
+// Exec calls f(req)
+func (f HTTPClientFunc) Exec(req *http.Request) ([]byte, *http.Response, error) {
+	return f(req)
+}
+
+// Exec executes notification hub http request and handles the response.
+// Failed requests are retried when the client has a retry policy.
 func (hc HubHTTPClient) Exec(req *http.Request) ([]byte, *http.Response, error) {
-	return handleResponse(hc.httpClient.Do(req))
+	return hc.execWithRetry(req, hc.read)
+}
+
+// Stream executes notification hub http request like Exec,
+// but returns a successful response with its body unread
+func (hc HubHTTPClient) Stream(req *http.Request) (*http.Response, error) {
+	_, resp, err := hc.execWithRetry(req, hc.open)
+	return resp, err
+}
+
+// read executes req once and reads the response body
+func (hc HubHTTPClient) read(req *http.Request) ([]byte, *http.Response, error) {
+	return hc.handleResponse(hc.httpClient.Do(req))
+}
+
+// open executes req once and leaves a successful response body unread
+func (hc HubHTTPClient) open(req *http.Request) ([]byte, *http.Response, error) {
+	resp, err := hc.httpClient.Do(req)
+	if err != nil || !isOKResponseCode(resp.StatusCode) {
+		return hc.handleResponse(resp, err)
+	}
+	return nil, resp, nil
 }
 
 // handleResponse reads http response body into byte slice
 // if response contains an unexpected status code, error is returned
-func handleResponse(resp *http.Response, inErr error) (b []byte, response *http.Response, err error) {
+func (hc HubHTTPClient) handleResponse(resp *http.Response, inErr error) (b []byte, response *http.Response, err error) {
 	if inErr != nil {
 		return nil, nil, inErr
 	}
@@ -44,13 +130,25 @@ func handleResponse(resp *http.Response, inErr error) (b []byte, response *http.
 	}()
 
 	response = resp
-	b, err = ioutil.ReadAll(resp.Body)
+	body := io.Reader(resp.Body)
+	if hc.maxResponseBytes > 0 {
+		body = io.LimitReader(resp.Body, hc.maxResponseBytes+1)
+	}
+	b, err = io.ReadAll(body)
 	if err != nil {
 		return nil, nil, err
 	}
+	tooLarge := hc.maxResponseBytes > 0 && int64(len(b)) > hc.maxResponseBytes
 
 	if !isOKResponseCode(resp.StatusCode) {
-		return nil, response, fmt.Errorf("Got unexpected response status code: %d. response: %s", resp.StatusCode, string(b))
+		if tooLarge {
+			b = b[:hc.maxResponseBytes]
+		}
+		return nil, response, &StatusError{Response: resp, Body: b}
+	}
+
+	if tooLarge {
+		return nil, response, &ResponseTooLargeError{Response: resp, Limit: hc.maxResponseBytes}
 	}
 
 	if len(b) == 0 {
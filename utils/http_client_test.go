@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.BaseDelay = time.Millisecond
+	p.MaxDelay = 5 * time.Millisecond
+	return p
+}
+
+func TestHubHTTPClient_RetriesAndReplaysBody(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt %d: expected body %q, got %q", calls, "payload", body)
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewHubHTTPClient(WithRetryPolicy(testRetryPolicy()))
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString("payload"))
+
+	_, resp, err := client.Exec(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestHubHTTPClient_ReplaysUnbufferedBody(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("expected body %q, got %q", "payload", body)
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHubHTTPClient(WithRetryPolicy(testRetryPolicy()))
+	req, _ := http.NewRequest(http.MethodPost, server.URL, io.MultiReader(strings.NewReader("payload")))
+
+	if _, _, err := client.Exec(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestHubHTTPClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewHubHTTPClient(WithRetryPolicy(testRetryPolicy()))
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	_, resp, err := client.Exec(req)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the 400 response to be returned, got %v", resp)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestHubHTTPClient_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 2
+	client := NewHubHTTPClient(WithRetryPolicy(policy))
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	if _, _, err := client.Exec(req); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestHubHTTPClient_RetryAfterBeyondDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	client := NewHubHTTPClient(WithRetryPolicy(testRetryPolicy()))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	_, resp, err := client.Exec(req)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the 429 response to be returned, got %v", resp)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to fail fast, took %s", elapsed)
+	}
+}
+
+func TestHubHTTPClient_ContextCancelledWhileWaiting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := testRetryPolicy()
+	policy.BaseDelay = time.Minute
+	policy.MaxDelay = time.Minute
+	client := NewHubHTTPClient(WithRetryPolicy(policy))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, _, err := client.Exec(req); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "", expected: 0, ok: false},
+		{value: "7", expected: 7 * time.Second, ok: true},
+		{value: "-1", expected: 0, ok: false},
+		{value: now.Add(90 * time.Second).Format(http.TimeFormat), expected: 90 * time.Second, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, ok: true},
+		{value: "soon", expected: 0, ok: false},
+	}
+
+	for _, tc := range testCases {
+		header := http.Header{}
+		if tc.value != "" {
+			header.Set("Retry-After", tc.value)
+		}
+		got, ok := ParseRetryAfter(header, now)
+		if got != tc.expected || ok != tc.ok {
+			t.Errorf("ParseRetryAfter(%q) = %s, %t; want %s, %t", tc.value, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("backoff(%d) = %s; want %s", i+1, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("backoff with jitter out of range: %s", got)
+		}
+	}
+}
+
+func TestHubHTTPClient_RetryHook(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	var attempts []int
+	ctx := ContextWithRetryHook(context.Background(), func(attempt int, delay time.Duration) {
+		attempts = append(attempts, attempt)
+	})
+	client := NewHubHTTPClient(WithRetryPolicy(testRetryPolicy()))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	if _, _, err := client.Exec(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("expected retry hook calls for attempts [1 2], got %v", attempts)
+	}
+}
+
+func TestHubHTTPClient_MaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	client := NewHubHTTPClient(WithMaxResponseBytes(10))
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, _, err := client.Exec(req)
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 10 {
+		t.Errorf("expected a ResponseTooLargeError with limit 10, got %v", err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/fail", nil)
+	_, _, err = client.Exec(req)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || len(statusErr.Body) != 10 {
+		t.Errorf("expected a StatusError with a truncated body, got %v", err)
+	}
+}
+
+func TestHubHTTPClient_Stream(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	client := NewHubHTTPClient(WithRetryPolicy(testRetryPolicy()), WithMaxResponseBytes(10)).(StreamingHTTPClient)
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	resp, err := client.Stream(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if len(body) != 100 {
+		t.Errorf("expected the unread body of 100 bytes, got %d", len(body))
+	}
+	if calls != 2 {
+		t.Errorf("expected the failed attempt to be retried, got %d calls", calls)
+	}
+}
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes when and how often a failed request is retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// A value of 1 or less disables retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on every following attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff delay
+	MaxDelay time.Duration
+	// Jitter is the fraction (0-1) of each backoff delay that is randomized
+	Jitter float64
+	// RetryableStatusCodes are the response status codes that trigger a retry.
+	// Transport errors are always retried.
+	RetryableStatusCodes []int
+}
+
+// RetryHook is called before a failed request is retried
+type RetryHook func(attempt int, delay time.Duration)
+
+// retryHookContextKey is the context key of the RetryHook
+type retryHookContextKey struct{}
+
+// ContextWithRetryHook returns a context making the client call hook before every retry
+func ContextWithRetryHook(ctx context.Context, hook RetryHook) context.Context {
+	return context.WithValue(ctx, retryHookContextKey{}, hook)
+}
+
+// DefaultRetryPolicy returns a policy retrying throttled and unavailable responses
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetryPolicy makes the client retry failed requests according to p
+func WithRetryPolicy(p RetryPolicy) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		hc.retryPolicy = &p
+	}
+}
+
+// WithRetries returns client retrying failed requests according to p.
+// A HubHTTPClient is returned as a copy using p instead of its own policy,
+// any other client is wrapped and keeps its transport and settings.
+func WithRetries(client HTTPClient, p RetryPolicy) HTTPClient {
+	if hc, ok := client.(HubHTTPClient); ok {
+		hc.retryPolicy = &p
+		return hc
+	}
+	return HTTPClientFunc(func(req *http.Request) ([]byte, *http.Response, error) {
+		return execWithRetry(&p, req, client.Exec)
+	})
+}
+
+// ParseRetryAfter reads the Retry-After header, which is either
+// a number of seconds or an HTTP date
+func ParseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// isRetryableStatus identifies whether code is listed in the policy
+func (p *RetryPolicy) isRetryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the exponential delay before the given retry attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// execWithRetry executes req with do until it succeeds, the client policy gives up
+// or the request context is done
+func (hc HubHTTPClient) execWithRetry(req *http.Request, do func(*http.Request) ([]byte, *http.Response, error)) ([]byte, *http.Response, error) {
+	return execWithRetry(hc.retryPolicy, req, do)
+}
+
+// execWithRetry executes req with do until it succeeds, policy gives up
+// or the request context is done
+func execWithRetry(policy *RetryPolicy, req *http.Request, do func(*http.Request) ([]byte, *http.Response, error)) (b []byte, resp *http.Response, err error) {
+	ctx := req.Context()
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return do(req)
+	}
+
+	if err = makeRewindable(req); err != nil {
+		return nil, nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		b, resp, err = do(req)
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return
+		}
+		if resp != nil && !policy.isRetryableStatus(resp.StatusCode) {
+			return
+		}
+
+		delay := policy.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := ParseRetryAfter(resp.Header, time.Now()); ok {
+				delay = retryAfter
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			// waiting would outlive the caller, report the last failure instead
+			return
+		}
+		if hook, ok := ctx.Value(retryHookContextKey{}).(RetryHook); ok && hook != nil {
+			hook(attempt, delay)
+		}
+		if werr := wait(ctx, delay); werr != nil {
+			return nil, nil, werr
+		}
+
+		if req, err = rewind(req); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// makeRewindable ensures the request body can be replayed through GetBody
+func makeRewindable(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if err = req.Body.Close(); err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
+
+// rewind returns a copy of req with a fresh body
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody == nil {
+		return next, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return next, nil
+}
+
+// wait sleeps for d or until ctx is done
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
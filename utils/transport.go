@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WithHTTPClient makes the client send requests through c, using the timeout of c.
+// Options applied after it modify a copy of c, never c itself.
+func WithHTTPClient(c *http.Client) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		copied := *c
+		hc.httpClient = &copied
+		hc.ownTransport = false
+	}
+}
+
+// WithTimeout limits the time of a single request attempt, including reading the response.
+// It replaces DefaultTimeout, 0 means no limit.
+func WithTimeout(d time.Duration) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		hc.httpClient.Timeout = d
+	}
+}
+
+// WithDialTimeout limits the time to establish a connection
+func WithDialTimeout(d time.Duration) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			t.DialContext = (&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext
+		}
+	}
+}
+
+// WithTLSHandshakeTimeout limits the time of the TLS handshake
+func WithTLSHandshakeTimeout(d time.Duration) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			t.TLSHandshakeTimeout = d
+		}
+	}
+}
+
+// WithIdleConnections configures the pool of idle keep-alive connections
+func WithIdleConnections(maxIdle, maxIdlePerHost int, idleTimeout time.Duration) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			t.MaxIdleConns = maxIdle
+			t.MaxIdleConnsPerHost = maxIdlePerHost
+			t.IdleConnTimeout = idleTimeout
+		}
+	}
+}
+
+// WithMaxConnsPerHost limits the number of connections to the hub, 0 means no limit
+func WithMaxConnsPerHost(n int) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			t.MaxConnsPerHost = n
+		}
+	}
+}
+
+// WithProxy sends all requests through the HTTP proxy at proxyURL.
+// By default the proxy is read from the environment.
+func WithProxy(proxyURL *url.URL) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			t.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+}
+
+// WithTLSConfig makes the client use cfg for TLS connections
+func WithTLSConfig(cfg *tls.Config) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			t.TLSClientConfig = cfg.Clone()
+		}
+	}
+}
+
+// WithRootCAs makes the client trust the certificate authorities in pool instead of the system ones
+func WithRootCAs(pool *x509.CertPool) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+			if t.TLSClientConfig != nil {
+				cfg = t.TLSClientConfig.Clone()
+			}
+			cfg.RootCAs = pool
+			t.TLSClientConfig = cfg
+		}
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2, which is attempted by default
+func WithHTTP2(enabled bool) HubHTTPClientOption {
+	return func(hc *HubHTTPClient) {
+		if t := hc.transport(); t != nil {
+			t.ForceAttemptHTTP2 = enabled
+			if enabled {
+				// a nil map lets the transport configure HTTP/2 again
+				t.TLSNextProto = nil
+			} else {
+				// a non-nil empty map disables the automatic HTTP/2 upgrade
+				t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			}
+		}
+	}
+}
+
+// transport returns the *http.Transport owned by the client, cloning the
+// current one on first use. It returns nil when a custom RoundTripper is used.
+func (hc *HubHTTPClient) transport() *http.Transport {
+	if hc.ownTransport {
+		return hc.httpClient.Transport.(*http.Transport)
+	}
+
+	base := hc.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t, ok := base.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	t = t.Clone()
+	hc.httpClient.Transport = t
+	hc.ownTransport = true
+	return t
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestHubHTTPClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewHubHTTPClient(WithTimeout(20 * time.Millisecond))
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	if _, _, err := client.Exec(req); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("expected the request to time out early, took %s", elapsed)
+	}
+}
+
+func TestHubHTTPClient_WithHTTPClientIsNotModified(t *testing.T) {
+	var calls int32
+	custom := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	})}
+
+	client := NewHubHTTPClient(WithHTTPClient(custom), WithTimeout(time.Second), WithDialTimeout(time.Second))
+	req, _ := http.NewRequest(http.MethodGet, "http://hub.invalid", nil)
+
+	if _, _, err := client.Exec(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the custom transport to be used once, got %d", calls)
+	}
+	if custom.Timeout != 0 {
+		t.Errorf("expected the given client to be left unchanged, got timeout %s", custom.Timeout)
+	}
+}
+
+func TestHubHTTPClient_TransportSettings(t *testing.T) {
+	proxy, _ := url.Parse("http://proxy.local:3128")
+	client := NewHubHTTPClient(
+		WithIdleConnections(10, 5, time.Minute),
+		WithMaxConnsPerHost(8),
+		WithTLSHandshakeTimeout(3*time.Second),
+		WithProxy(proxy),
+		WithHTTP2(false),
+	).(HubHTTPClient)
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", client.httpClient.Transport)
+	}
+	if transport == http.DefaultTransport {
+		t.Fatal("expected http.DefaultTransport to be cloned")
+	}
+	if transport.MaxIdleConns != 10 || transport.MaxIdleConnsPerHost != 5 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("unexpected idle connection settings: %d %d %s", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport.MaxConnsPerHost != 8 || transport.TLSHandshakeTimeout != 3*time.Second {
+		t.Errorf("unexpected connection settings: %d %s", transport.MaxConnsPerHost, transport.TLSHandshakeTimeout)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://hub.servicebus.windows.net", nil)
+	if got, _ := transport.Proxy(req); got == nil || got.String() != proxy.String() {
+		t.Errorf("expected proxy %s, got %v", proxy, got)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("expected HTTP/2 to be disabled")
+	}
+}
+
+func TestHubHTTPClient_RootCAs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, _, err := NewHubHTTPClient().Exec(req); err == nil {
+		t.Fatal("expected the self signed certificate to be rejected")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, _, err := NewHubHTTPClient(WithRootCAs(pool)).Exec(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHubHTTPClient_DefaultTimeout(t *testing.T) {
+	if client := NewHubHTTPClient().(HubHTTPClient); client.httpClient.Timeout != DefaultTimeout {
+		t.Errorf("expected the default timeout %s, got %s", DefaultTimeout, client.httpClient.Timeout)
+	}
+	if client := NewHubHTTPClient(WithTimeout(0)).(HubHTTPClient); client.httpClient.Timeout != 0 {
+		t.Errorf("expected no timeout, got %s", client.httpClient.Timeout)
+	}
+}
+
+func TestHubHTTPClient_HTTP2CanBeEnabledAgain(t *testing.T) {
+	client := NewHubHTTPClient(WithHTTP2(false), WithHTTP2(true)).(HubHTTPClient)
+
+	transport := client.httpClient.Transport.(*http.Transport)
+	if !transport.ForceAttemptHTTP2 || transport.TLSNextProto != nil {
+		t.Error("expected HTTP/2 to be enabled")
+	}
+}
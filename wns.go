@@ -0,0 +1,277 @@
+package notificationhubs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// WNS headers
+const (
+	wnsTypeHeader        = "X-WNS-Type"
+	wnsCachePolicyHeader = "X-WNS-Cache-Policy"
+	wnsTTLHeader         = "X-WNS-TTL"
+	wnsTagHeader         = "X-WNS-Tag"
+)
+
+// WnsMaxPayloadSize is the largest payload WNS accepts, in bytes
+const WnsMaxPayloadSize = 5000
+
+// WNS notification types
+const (
+	WnsToast WnsType = "wns/toast"
+	WnsTile  WnsType = "wns/tile"
+	WnsBadge WnsType = "wns/badge"
+	WnsRaw   WnsType = "wns/raw"
+)
+
+// WNS cache policies
+const (
+	WnsCache   WnsCachePolicy = "cache"
+	WnsNoCache WnsCachePolicy = "no-cache"
+)
+
+// wnsBadgeGlyphs are the glyphs a badge can show instead of a number
+var wnsBadgeGlyphs = map[string]bool{
+	"none": true, "activity": true, "alarm": true, "alert": true, "attention": true, "available": true,
+	"away": true, "busy": true, "error": true, "newMessage": true, "paused": true, "playing": true, "unavailable": true,
+}
+
+type (
+	// WnsOptions are the WNS headers common to all notification types
+	WnsOptions struct {
+		// CachePolicy caches the notification while the device is offline, tile, badge and raw only
+		CachePolicy WnsCachePolicy
+		// TTL is how long the notification is valid, in whole seconds
+		TTL time.Duration
+		// Tag replaces an earlier notification with the same tag, toast and tile only
+		Tag string
+	}
+
+	// WnsToastNotification is a toast built on the ToastGeneric template
+	WnsToastNotification struct {
+		WnsOptions
+		// Launch is passed to the app when the toast is clicked
+		Launch string
+		// Long shows the toast for 25 seconds instead of 7
+		Long bool
+		// Texts are the title and body lines, up to 3
+		Texts []string
+		// Image is shown as the app logo override
+		Image string
+		// Sound is an ms-winsoundevent: or ms-appx: sound, Silent mutes the toast
+		Sound  string
+		Silent bool
+	}
+
+	// WnsTileNotification updates a live tile
+	WnsTileNotification struct {
+		WnsOptions
+		// Bindings are the content per tile size
+		Bindings []WnsTileBinding
+	}
+
+	// WnsTileBinding is the content of one tile size
+	WnsTileBinding struct {
+		// Template is the tile size, such as TileMedium or TileWide
+		Template string
+		Texts    []string
+		Image    string
+	}
+
+	// WnsBadgeNotification updates the badge of the app tile
+	WnsBadgeNotification struct {
+		WnsOptions
+		// Value is a number or a glyph such as alert or newMessage
+		Value string
+	}
+
+	// WnsRawNotification delivers an application defined payload to the app
+	WnsRawNotification struct {
+		WnsOptions
+		Payload []byte
+	}
+
+	// WnsType is the X-WNS-Type of a notification
+	WnsType string
+
+	// WnsCachePolicy is the X-WNS-Cache-Policy of a notification
+	WnsCachePolicy string
+
+	// wnsText is a text element of a toast or tile binding
+	wnsText struct {
+		Value string `xml:",chardata"`
+	}
+
+	// wnsImage is an image element of a toast or tile binding
+	wnsImage struct {
+		Placement string `xml:"placement,attr,omitempty"`
+		Src       string `xml:"src,attr"`
+	}
+
+	// wnsBinding is the binding element of toasts and tiles
+	wnsBinding struct {
+		Template string    `xml:"template,attr"`
+		Texts    []wnsText `xml:"text"`
+		Image    *wnsImage `xml:"image,omitempty"`
+	}
+
+	// wnsToastXML is the XML of a toast
+	wnsToastXML struct {
+		XMLName  xml.Name       `xml:"toast"`
+		Launch   string         `xml:"launch,attr,omitempty"`
+		Duration string         `xml:"duration,attr,omitempty"`
+		Bindings []wnsBinding   `xml:"visual>binding"`
+		Audio    *wnsToastAudio `xml:"audio,omitempty"`
+	}
+
+	// wnsToastAudio is the audio element of a toast
+	wnsToastAudio struct {
+		Src    string `xml:"src,attr,omitempty"`
+		Silent bool   `xml:"silent,attr,omitempty"`
+	}
+
+	// wnsTileXML is the XML of a tile
+	wnsTileXML struct {
+		XMLName  xml.Name     `xml:"tile"`
+		Bindings []wnsBinding `xml:"visual>binding"`
+	}
+
+	// wnsBadgeXML is the XML of a badge
+	wnsBadgeXML struct {
+		XMLName xml.Name `xml:"badge"`
+		Value   string   `xml:"value,attr"`
+	}
+)
+
+// NewWnsToast returns a toast showing title and body
+func NewWnsToast(title, body string) *WnsToastNotification {
+	return &WnsToastNotification{Texts: []string{title, body}}
+}
+
+// Build validates the toast and returns it as a WindowsFormat notification
+func (t *WnsToastNotification) Build() (*Notification, error) {
+	if len(t.Texts) == 0 || len(t.Texts) > 3 {
+		return nil, NewValidationError("Texts", "must have 1 to 3 lines", len(t.Texts))
+	}
+	toast := wnsToastXML{
+		Launch:   t.Launch,
+		Bindings: []wnsBinding{newWnsBinding("ToastGeneric", t.Texts, t.Image, "appLogoOverride")},
+	}
+	if t.Long {
+		toast.Duration = "long"
+	}
+	if t.Sound != "" || t.Silent {
+		toast.Audio = &wnsToastAudio{Src: t.Sound, Silent: t.Silent}
+	}
+	return buildWnsNotification(WnsToast, t.WnsOptions, toast)
+}
+
+// Build validates the tile and returns it as a WindowsFormat notification
+func (t *WnsTileNotification) Build() (*Notification, error) {
+	if len(t.Bindings) == 0 {
+		return nil, NewValidationError("Bindings", "needs at least one tile size", nil)
+	}
+	tile := wnsTileXML{}
+	for _, binding := range t.Bindings {
+		if binding.Template == "" {
+			return nil, NewValidationError("Bindings.Template", "is required", binding.Template)
+		}
+		tile.Bindings = append(tile.Bindings, newWnsBinding(binding.Template, binding.Texts, binding.Image, ""))
+	}
+	return buildWnsNotification(WnsTile, t.WnsOptions, tile)
+}
+
+// Build validates the badge and returns it as a WindowsFormat notification
+func (b *WnsBadgeNotification) Build() (*Notification, error) {
+	if n, err := strconv.Atoi(b.Value); (err != nil || n < 0) && !wnsBadgeGlyphs[b.Value] {
+		return nil, NewValidationError("Value", "must be a number or a badge glyph", b.Value)
+	}
+	return buildWnsNotification(WnsBadge, b.WnsOptions, wnsBadgeXML{Value: b.Value})
+}
+
+// Build validates the raw notification and returns it as a WindowsFormat notification
+// sent as application/octet-stream
+func (r *WnsRawNotification) Build() (*Notification, error) {
+	if len(r.Payload) == 0 {
+		return nil, NewValidationError("Payload", "must not be empty", nil)
+	}
+	return newWnsNotification(WnsRaw, r.WnsOptions, r.Payload)
+}
+
+// headers returns the WNS headers of a wnsType notification
+func (o *WnsOptions) headers(wnsType WnsType) (Headers, error) {
+	headers := Headers{wnsTypeHeader: string(wnsType)}
+
+	switch o.CachePolicy {
+	case "":
+	case WnsCache, WnsNoCache:
+		if wnsType == WnsToast {
+			return nil, NewValidationError("CachePolicy", "is not supported by toasts", o.CachePolicy)
+		}
+		headers[wnsCachePolicyHeader] = string(o.CachePolicy)
+	default:
+		return nil, NewValidationError("CachePolicy", "must be cache or no-cache", o.CachePolicy)
+	}
+
+	switch {
+	case o.TTL < 0 || o.TTL%time.Second != 0:
+		return nil, NewValidationError("TTL", "must be a positive number of seconds", o.TTL)
+	case o.TTL > 0:
+		headers[wnsTTLHeader] = strconv.FormatInt(int64(o.TTL/time.Second), 10)
+	}
+
+	if o.Tag != "" {
+		maxTag := 16
+		switch wnsType {
+		case WnsToast:
+			maxTag = 64
+		case WnsBadge, WnsRaw:
+			return nil, NewValidationError("Tag", "is only supported by toasts and tiles", o.Tag)
+		}
+		if len(o.Tag) > maxTag {
+			return nil, NewValidationError("Tag", fmt.Sprintf("must not be longer than %d characters", maxTag), o.Tag)
+		}
+		headers[wnsTagHeader] = o.Tag
+	}
+	return headers, nil
+}
+
+// newWnsBinding returns a binding with texts and an optional image
+func newWnsBinding(template string, texts []string, image, placement string) wnsBinding {
+	binding := wnsBinding{Template: template}
+	for _, text := range texts {
+		binding.Texts = append(binding.Texts, wnsText{Value: text})
+	}
+	if image != "" {
+		binding.Image = &wnsImage{Placement: placement, Src: image}
+	}
+	return binding
+}
+
+// buildWnsNotification encodes the XML of a toast, tile or badge notification
+func buildWnsNotification(wnsType WnsType, options WnsOptions, v interface{}) (*Notification, error) {
+	payload, err := xml.Marshal(v)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrorCodeInvalidPayload, "cannot encode WNS notification", err)
+	}
+	return newWnsNotification(wnsType, options, payload)
+}
+
+// newWnsNotification returns a WindowsFormat notification carrying the WNS headers
+func newWnsNotification(wnsType WnsType, options WnsOptions, payload []byte) (*Notification, error) {
+	headers, err := options.headers(wnsType)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > WnsMaxPayloadSize {
+		return nil, NewError(ErrorCodePayloadTooLarge, fmt.Sprintf("WNS payload is %d bytes, the limit is %d", len(payload), WnsMaxPayloadSize))
+	}
+	n, err := newNotification(WindowsFormat, payload)
+	if err != nil {
+		return nil, err
+	}
+	n.Headers = headers
+	return n, nil
+}
@@ -0,0 +1,163 @@
+package notificationhubs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/koreset/azure-notificationhubs-sdk-go"
+)
+
+func Test_WnsBuilders(t *testing.T) {
+	toast := NewWnsToast("Order shipped", "Your order is on its way")
+	toast.Launch = "orders/42"
+	toast.Long = true
+	toast.Sound = "ms-winsoundevent:Notification.Mail"
+	toast.Tag = "order-42"
+	toast.TTL = time.Hour
+
+	tests := []struct {
+		name    string
+		build   func() (*Notification, error)
+		payload string
+		headers Headers
+	}{
+		{
+			name:    "toast",
+			build:   toast.Build,
+			payload: `<toast launch="orders/42" duration="long"><visual><binding template="ToastGeneric"><text>Order shipped</text><text>Your order is on its way</text></binding></visual><audio src="ms-winsoundevent:Notification.Mail"></audio></toast>`,
+			headers: Headers{"X-WNS-Type": "wns/toast", "X-WNS-TTL": "3600", "X-WNS-Tag": "order-42"},
+		},
+		{
+			name: "tile",
+			build: (&WnsTileNotification{
+				WnsOptions: WnsOptions{CachePolicy: WnsCache},
+				Bindings:   []WnsTileBinding{{Template: "TileMedium", Texts: []string{"3 orders"}, Image: "ms-appx:///tile.png"}},
+			}).Build,
+			payload: `<tile><visual><binding template="TileMedium"><text>3 orders</text><image src="ms-appx:///tile.png"></image></binding></visual></tile>`,
+			headers: Headers{"X-WNS-Type": "wns/tile", "X-WNS-Cache-Policy": "cache"},
+		},
+		{
+			name:    "badge",
+			build:   (&WnsBadgeNotification{Value: "newMessage"}).Build,
+			payload: `<badge value="newMessage"></badge>`,
+			headers: Headers{"X-WNS-Type": "wns/badge"},
+		},
+		{
+			name:    "raw",
+			build:   (&WnsRawNotification{Payload: []byte{0x01, 0x02}, WnsOptions: WnsOptions{CachePolicy: WnsNoCache}}).Build,
+			payload: "\x01\x02",
+			headers: Headers{"X-WNS-Type": "wns/raw", "X-WNS-Cache-Policy": "no-cache"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notification, err := test.build()
+			if err != nil {
+				t.Fatalf(errfmt, "error", nil, err)
+			}
+			if notification.Format != WindowsFormat {
+				t.Errorf(errfmt, "format", WindowsFormat, notification.Format)
+			}
+			if string(notification.Payload) != test.payload {
+				t.Errorf(errfmt, "payload", test.payload, string(notification.Payload))
+			}
+			if !reflect.DeepEqual(notification.Headers, test.headers) {
+				t.Errorf(errfmt, "headers", test.headers, notification.Headers)
+			}
+			wantType := "application/xml"
+			if test.name == "raw" {
+				wantType = "application/octet-stream"
+			}
+			if got := notification.GetContentType(); got != wantType {
+				t.Errorf(errfmt, "Content-Type", wantType, got)
+			}
+		})
+	}
+}
+
+func Test_WnsValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (*Notification, error)
+		field string
+	}{
+		{name: "toast without text", build: (&WnsToastNotification{}).Build, field: "Texts"},
+		{name: "toast cache policy", build: (&WnsToastNotification{Texts: []string{"x"}, WnsOptions: WnsOptions{CachePolicy: WnsCache}}).Build, field: "CachePolicy"},
+		{name: "unknown cache policy", build: (&WnsBadgeNotification{Value: "1", WnsOptions: WnsOptions{CachePolicy: "always"}}).Build, field: "CachePolicy"},
+		{name: "fractional ttl", build: (&WnsBadgeNotification{Value: "1", WnsOptions: WnsOptions{TTL: 1500 * time.Millisecond}}).Build, field: "TTL"},
+		{name: "tile tag too long", build: (&WnsTileNotification{Bindings: []WnsTileBinding{{Template: "TileSmall"}}, WnsOptions: WnsOptions{Tag: strings.Repeat("t", 17)}}).Build, field: "Tag"},
+		{name: "badge tag", build: (&WnsBadgeNotification{Value: "1", WnsOptions: WnsOptions{Tag: "t"}}).Build, field: "Tag"},
+		{name: "tile without template", build: (&WnsTileNotification{Bindings: []WnsTileBinding{{}}}).Build, field: "Bindings.Template"},
+		{name: "unknown badge glyph", build: (&WnsBadgeNotification{Value: "smile"}).Build, field: "Value"},
+		{name: "empty raw", build: (&WnsRawNotification{}).Build, field: "Payload"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.build()
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != test.field {
+				t.Errorf(errfmt, "validation error", test.field, err)
+			}
+		})
+	}
+}
+
+func Test_WnsHeadersAreSent(t *testing.T) {
+	var (
+		nhub, mockClient = initTestItems()
+		notification, _  = (&WnsRawNotification{Payload: []byte("raw"), WnsOptions: WnsOptions{TTL: time.Minute}}).Build()
+		requests         []*http.Request
+		batchPartType    string
+	)
+	notification.Headers["ServiceBusNotification-Format"] = "apple"
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, *http.Response, error) {
+		requests = append(requests, req)
+		if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+			part, _ := multipart.NewReader(req.Body, params["boundary"]).NextPart()
+			batchPartType = part.Header.Get("Content-Type")
+			_, _ = io.Copy(io.Discard, part)
+		}
+		return nil, &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}, nil
+	}
+
+	ctx := context.Background()
+	if _, _, err := nhub.Send(ctx, notification, nil); err != nil {
+		t.Fatalf(errfmt, "Send error", nil, err)
+	}
+	if _, _, err := nhub.SendDirect(ctx, notification, "channel"); err != nil {
+		t.Fatalf(errfmt, "SendDirect error", nil, err)
+	}
+	if _, _, err := nhub.SendDirectBatch(ctx, notification, "channel-one", "channel-two"); err != nil {
+		t.Fatalf(errfmt, "SendDirectBatch error", nil, err)
+	}
+
+	for i, req := range requests {
+		if got := req.Header.Get("X-WNS-Type"); got != "wns/raw" {
+			t.Errorf(errfmt, "X-WNS-Type", "wns/raw", got)
+		}
+		if got := req.Header.Get("X-WNS-TTL"); got != "60" {
+			t.Errorf(errfmt, "X-WNS-TTL", "60", got)
+		}
+		if got := req.Header.Get("ServiceBusNotification-Format"); got != "windows" {
+			t.Errorf(errfmt, "ServiceBusNotification-Format", "windows", got)
+		}
+		if got := req.Header.Get("Content-Type"); i < 2 && got != "application/octet-stream" {
+			t.Errorf(errfmt, "Content-Type", "application/octet-stream", got)
+		}
+	}
+	if batchPartType != "application/octet-stream" {
+		t.Errorf(errfmt, "batch notification Content-Type", "application/octet-stream", batchPartType)
+	}
+}